@@ -26,6 +26,7 @@ import (
 	"github.com/offchainlabs/nitro/arbos/l2pricing"
 	"github.com/offchainlabs/nitro/arbos/merkleAccumulator"
 	"github.com/offchainlabs/nitro/arbos/retryables"
+	"github.com/offchainlabs/nitro/arbos/statistics"
 	"github.com/offchainlabs/nitro/arbos/storage"
 	"github.com/offchainlabs/nitro/arbos/util"
 )
@@ -47,6 +48,7 @@ type ArbosState struct {
 	chainOwners            *addressSet.AddressSet
 	sendMerkle             *merkleAccumulator.MerkleAccumulator
 	blockhashes            *blockhash.Blockhashes
+	statistics             *statistics.Statistics
 	chainId                storage.StorageBackedBigInt
 	chainConfig            storage.StorageBackedBytes
 	genesisBlockNum        storage.StorageBackedUint64
@@ -80,6 +82,7 @@ func OpenArbosState(stateDB vm.StateDB, burner burn.Burner) (*ArbosState, error)
 		addressSet.OpenAddressSet(backingStorage.OpenCachedSubStorage(chainOwnerSubspace)),
 		merkleAccumulator.OpenMerkleAccumulator(backingStorage.OpenCachedSubStorage(sendMerkleSubspace)),
 		blockhash.OpenBlockhashes(backingStorage.OpenCachedSubStorage(blockhashesSubspace)),
+		statistics.Open(backingStorage.OpenCachedSubStorage(statisticsSubspace)),
 		backingStorage.OpenStorageBackedBigInt(uint64(chainIdOffset)),
 		backingStorage.OpenStorageBackedBytes(chainConfigSubspace),
 		backingStorage.OpenStorageBackedUint64(uint64(genesisBlockNumOffset)),
@@ -156,6 +159,7 @@ var (
 	sendMerkleSubspace   SubspaceID = []byte{5}
 	blockhashesSubspace  SubspaceID = []byte{6}
 	chainConfigSubspace  SubspaceID = []byte{7}
+	statisticsSubspace   SubspaceID = []byte{8}
 )
 
 // Returns a list of precompiles that only appear in Arbitrum chains (i.e. ArbOS precompiles) at the genesis block
@@ -231,6 +235,7 @@ func InitializeArbosState(stateDB vm.StateDB, burner burn.Burner, chainConfig *p
 	addressTable.Initialize(sto.OpenCachedSubStorage(addressTableSubspace))
 	merkleAccumulator.InitializeMerkleAccumulator(sto.OpenCachedSubStorage(sendMerkleSubspace))
 	blockhash.InitializeBlockhashes(sto.OpenCachedSubStorage(blockhashesSubspace))
+	statistics.Initialize(sto.OpenCachedSubStorage(statisticsSubspace))
 
 	ownersStorage := sto.OpenCachedSubStorage(chainOwnerSubspace)
 	_ = addressSet.Initialize(ownersStorage)
@@ -431,6 +436,10 @@ func (state *ArbosState) Blockhashes() *blockhash.Blockhashes {
 	return state.blockhashes
 }
 
+func (state *ArbosState) Statistics() *statistics.Statistics {
+	return state.statistics
+}
+
 func (state *ArbosState) NetworkFeeAccount() (common.Address, error) {
 	return state.networkFeeAccount.Get()
 }
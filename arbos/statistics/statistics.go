@@ -0,0 +1,64 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package statistics
+
+import (
+	"math/big"
+
+	"github.com/offchainlabs/nitro/arbos/storage"
+)
+
+// Statistics holds the chain-wide counters ArbStatistics reports, as they stood when Classic was upgraded to Nitro
+type Statistics struct {
+	numAccounts  storage.StorageBackedBigUint
+	storageSum   storage.StorageBackedBigUint
+	gasSum       storage.StorageBackedBigUint
+	numTxes      storage.StorageBackedBigUint
+	numContracts storage.StorageBackedBigUint
+}
+
+const (
+	numAccountsOffset uint64 = iota
+	storageSumOffset
+	gasSumOffset
+	numTxesOffset
+	numContractsOffset
+)
+
+func Initialize(sto *storage.Storage) {
+	// no initialization needed, since the counters start at zero
+}
+
+func Open(sto *storage.Storage) *Statistics {
+	return &Statistics{
+		sto.OpenStorageBackedBigUint(numAccountsOffset),
+		sto.OpenStorageBackedBigUint(storageSumOffset),
+		sto.OpenStorageBackedBigUint(gasSumOffset),
+		sto.OpenStorageBackedBigUint(numTxesOffset),
+		sto.OpenStorageBackedBigUint(numContractsOffset),
+	}
+}
+
+// Get returns the counters in the order ArbStatistics reports them
+func (s *Statistics) Get() (numAccounts, storageSum, gasSum, numTxes, numContracts *big.Int, err error) {
+	counters := []*storage.StorageBackedBigUint{&s.numAccounts, &s.storageSum, &s.gasSum, &s.numTxes, &s.numContracts}
+	values := make([]*big.Int, len(counters))
+	for i, counter := range counters {
+		if values[i], err = counter.Get(); err != nil {
+			return nil, nil, nil, nil, nil, err
+		}
+	}
+	return values[0], values[1], values[2], values[3], values[4], nil
+}
+
+// Set records the counters, in the order Get returns them
+func (s *Statistics) Set(numAccounts, storageSum, gasSum, numTxes, numContracts *big.Int) error {
+	counters := []*storage.StorageBackedBigUint{&s.numAccounts, &s.storageSum, &s.gasSum, &s.numTxes, &s.numContracts}
+	for i, value := range []*big.Int{numAccounts, storageSum, gasSum, numTxes, numContracts} {
+		if err := counters[i].SetChecked(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -47,6 +47,9 @@ type TxProcessor struct {
 	CurrentRetryable *common.Hash
 	CurrentRefundTo  *common.Address
 
+	// How deeply each reentrancy-guarded precompile is executing in this tx's call stack
+	precompileDepths map[common.Address]int
+
 	// Caches for the latest L1 block number and hash,
 	// for the NUMBER and BLOCKHASH opcodes.
 	cachedL1BlockNumber *uint64
@@ -67,6 +70,7 @@ func NewTxProcessor(evm *vm.EVM, msg *core.Message) *TxProcessor {
 		evm:                 evm,
 		CurrentRetryable:    nil,
 		CurrentRefundTo:     nil,
+		precompileDepths:    make(map[common.Address]int),
 		cachedL1BlockNumber: nil,
 		cachedL1BlockHashes: make(map[uint64]common.Hash),
 	}
@@ -80,6 +84,25 @@ func (p *TxProcessor) PopCaller() {
 	p.Callers = p.Callers[:len(p.Callers)-1]
 }
 
+// EnterPrecompile records a call to the precompile, returning false if it was already executing
+func (p *TxProcessor) EnterPrecompile(addr common.Address) bool {
+	if p.precompileDepths == nil {
+		p.precompileDepths = make(map[common.Address]int)
+	}
+	p.precompileDepths[addr]++
+	return p.precompileDepths[addr] == 1
+}
+
+// ExitPrecompile records the end of a call recorded by EnterPrecompile.
+// Exits without a matching enter are ignored rather than driving the depth negative.
+func (p *TxProcessor) ExitPrecompile(addr common.Address) {
+	if p.precompileDepths[addr] <= 1 {
+		delete(p.precompileDepths, addr)
+		return
+	}
+	p.precompileDepths[addr]--
+}
+
 // Attempts to subtract up to `take` from `pool` without going negative.
 // Returns the amount subtracted from `pool`.
 func takeFunds(pool *big.Int, take *big.Int) *big.Int {
@@ -0,0 +1,30 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbos
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestPrecompileDepths(t *testing.T) {
+	addr := common.HexToAddress("0x6e")
+
+	// exiting before ever entering must not panic or leave a negative depth
+	p := &TxProcessor{}
+	p.ExitPrecompile(addr)
+	if !p.EnterPrecompile(addr) {
+		Fail(t, "an unmatched exit left the precompile executing")
+	}
+
+	if p.EnterPrecompile(addr) {
+		Fail(t, "reentering the precompile wasn't detected")
+	}
+	p.ExitPrecompile(addr)
+	p.ExitPrecompile(addr)
+	if !p.EnterPrecompile(addr) {
+		Fail(t, "the precompile still appears to be executing after exiting")
+	}
+}
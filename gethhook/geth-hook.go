@@ -10,10 +10,19 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/offchainlabs/nitro/arbos"
 	"github.com/offchainlabs/nitro/precompiles"
 )
 
+// Counts the reverts precompiles explain with a sentinel error, which geth never sees since RunAdvanced unwraps them
+var precompileRevertCounters = map[error]metrics.Counter{
+	precompiles.ErrDelegatecall:    metrics.NewRegisteredCounter("arb/precompiles/revert/delegatecall", nil),
+	precompiles.ErrNotActingAsSelf: metrics.NewRegisteredCounter("arb/precompiles/revert/notself", nil),
+	precompiles.ErrReadOnlyCall:    metrics.NewRegisteredCounter("arb/precompiles/revert/readonly", nil),
+	precompiles.ErrNonPayableValue: metrics.NewRegisteredCounter("arb/precompiles/revert/nonpayable", nil),
+}
+
 type ArbosPrecompileWrapper struct {
 	inner precompiles.ArbosPrecompile
 }
@@ -37,10 +46,20 @@ func (p ArbosPrecompileWrapper) RunAdvanced(
 	info.Evm.IncrementDepth()
 	defer info.Evm.DecrementDepth()
 
-	return p.inner.Call(
+	ret, gasLeft, err = p.inner.Call(
 		input, info.PrecompileAddress, info.ActingAsAddress,
 		info.Caller, info.Value, info.ReadOnly, gasSupplied, info.Evm,
 	)
+	if errors.Is(err, vm.ErrExecutionReverted) {
+		for sentinel, counter := range precompileRevertCounters {
+			if errors.Is(err, sentinel) {
+				counter.Inc(1)
+			}
+		}
+		// geth compares errors by identity, so unwrap reverts to keep the gas left & revert data
+		err = vm.ErrExecutionReverted
+	}
+	return ret, gasLeft, err
 }
 
 func init() {
@@ -55,13 +74,19 @@ func init() {
 		vm.PrecompiledContractsArbitrum[k] = v
 	}
 
+	arbosPrecompiles, err := precompiles.Precompiles()
+	if err != nil {
+		log.Crit("failed to make ArbOS precompiles", "err", err)
+	}
+
 	precompileErrors := make(map[[4]byte]abi.Error)
-	for addr, precompile := range precompiles.Precompiles() {
+	for addr, precompile := range arbosPrecompiles {
 		for _, errABI := range precompile.Precompile().GetErrorABIs() {
 			var id [4]byte
 			copy(id[:], errABI.ID[:4])
 			precompileErrors[id] = errABI
 		}
+		precompiles.AuditGasCosts(precompile.Precompile())
 		var wrapped vm.AdvancedPrecompile = ArbosPrecompileWrapper{precompile}
 		vm.PrecompiledContractsArbitrum[addr] = wrapped
 		vm.PrecompiledAddressesArbitrum = append(vm.PrecompiledAddressesArbitrum, addr)
@@ -41,11 +41,17 @@ func init() {
 
 	nodeInterfaceImpl := &NodeInterface{Address: types.NodeInterfaceAddress}
 	nodeInterfaceMeta := node_interfacegen.NodeInterfaceMetaData
-	_, nodeInterface := precompiles.MakePrecompile(nodeInterfaceMeta, nodeInterfaceImpl)
+	_, nodeInterface, err := precompiles.MakePrecompile(nodeInterfaceMeta, nodeInterfaceImpl)
+	if err != nil {
+		log.Crit("failed to make NodeInterface precompile", "err", err)
+	}
 
 	nodeInterfaceDebugImpl := &NodeInterfaceDebug{Address: types.NodeInterfaceDebugAddress}
 	nodeInterfaceDebugMeta := node_interfacegen.NodeInterfaceDebugMetaData
-	_, nodeInterfaceDebug := precompiles.MakePrecompile(nodeInterfaceDebugMeta, nodeInterfaceDebugImpl)
+	_, nodeInterfaceDebug, err := precompiles.MakePrecompile(nodeInterfaceDebugMeta, nodeInterfaceDebugImpl)
+	if err != nil {
+		log.Crit("failed to make NodeInterfaceDebug precompile", "err", err)
+	}
 
 	core.InterceptRPCMessage = func(
 		msg *core.Message,
@@ -150,10 +150,196 @@ func TestAddressTableCompressInTable(t *testing.T) {
 	}
 }
 
+func TestAddressTableRoundTripThroughCall(t *testing.T) {
+	evm := newMockEVMForTesting()
+	precompiles, err := Precompiles()
+	Require(t, err)
+	tableAddr := common.HexToAddress("66")
+	table := precompiles[tableAddr].Precompile()
+
+	addr := common.BytesToAddress(crypto.Keccak256([]byte{0x16})[:20])
+	if exists, _ := callTestMethod(t, table, evm, "AddressExists", addr)[0].(bool); exists {
+		Fail(t, "address shouldn't exist before registration")
+	}
+
+	index, _ := callTestMethod(t, table, evm, "Register", addr)[0].(*big.Int)
+	if index.Sign() != 0 {
+		Fail(t, "expected the first registration to take index 0 but got", index)
+	}
+	if exists, _ := callTestMethod(t, table, evm, "AddressExists", addr)[0].(bool); !exists {
+		Fail(t, "address should exist after registration")
+	}
+	if lookup, _ := callTestMethod(t, table, evm, "Lookup", addr)[0].(*big.Int); lookup.Cmp(index) != 0 {
+		Fail(t, "lookup returned", lookup, "instead of", index)
+	}
+	if found, _ := callTestMethod(t, table, evm, "LookupIndex", index)[0].(common.Address); found != addr {
+		Fail(t, "lookupIndex returned", found, "instead of", addr)
+	}
+
+	compressed, _ := callTestMethod(t, table, evm, "Compress", addr)[0].([]byte)
+	if len(compressed) > 9 {
+		Fail(t, "registered address compressed to", len(compressed), "bytes")
+	}
+
+	padded := append([]byte{0xaa}, compressed...)
+	results := callTestMethod(t, table, evm, "Decompress", padded, big.NewInt(1))
+	decompressed, _ := results[0].(common.Address)
+	consumed, _ := results[1].(*big.Int)
+	if decompressed != addr {
+		Fail(t, "decompressed", decompressed, "instead of", addr)
+	}
+	if consumed.Int64() != int64(len(compressed)) {
+		Fail(t, "decompress consumed", consumed, "bytes instead of", len(compressed))
+	}
+}
+
+func TestAddressTableDecodeInput(t *testing.T) {
+	precompiles, err := Precompiles()
+	Require(t, err)
+	table := precompiles[common.HexToAddress("66")].Precompile()
+
+	addr := common.BytesToAddress(crypto.Keccak256([]byte{0x54})[:20])
+	input := packTestCall(t, table, "Lookup", addr)
+	method, args, err := table.DecodeInput(input)
+	Require(t, err)
+	if method != "Lookup" {
+		Fail(t, "decoded the wrong method", method)
+	}
+	if len(args) != 1 {
+		Fail(t, "unexpected args", args)
+	}
+	for _, value := range args {
+		if value != addr {
+			Fail(t, "decoded", value, "instead of", addr)
+		}
+	}
+
+	if _, _, err := table.DecodeInput([]byte{0xde, 0xad, 0xbe, 0xef}); err == nil {
+		Fail(t, "decoded an unknown selector")
+	}
+	if _, _, err := table.DecodeInput(input[:20]); err == nil {
+		Fail(t, "decoded truncated calldata")
+	}
+	if _, _, err := table.DecodeInput(input[:2]); err == nil {
+		Fail(t, "decoded calldata without a selector")
+	}
+}
+
+func TestAddressTableReplayLookup(t *testing.T) {
+	evm := newMockEVMForTesting()
+	addr := common.BytesToAddress(crypto.Keccak256([]byte{0x75})[:20])
+	_, err := ArbAddressTable{}.Register(testContext(common.Address{}, evm), evm, addr)
+	Require(t, err)
+
+	precompiles, err := Precompiles()
+	Require(t, err)
+	tableAddr := common.HexToAddress("66")
+	table := precompiles[tableAddr]
+	input := packTestCall(t, table.Precompile(), "Lookup", addr)
+
+	var accesses []StateAccess
+	record := func(access StateAccess) {
+		accesses = append(accesses, access)
+	}
+	output, _, err := ReplayCall(table, input, tableAddr, common.Address{}, big.NewInt(0), true, 1000000, evm, record)
+	Require(t, err)
+	if new(big.Int).SetBytes(output).Sign() != 0 {
+		Fail(t, "the replayed lookup returned", output)
+	}
+
+	if len(accesses) == 0 {
+		Fail(t, "no state accesses were recorded")
+	}
+	for _, access := range accesses {
+		if access.Write {
+			Fail(t, "a lookup wrote to the state", access)
+		}
+	}
+	if _, ok := evm.StateDB.(*recordingStateDB); ok {
+		Fail(t, "the evm's state wasn't restored")
+	}
+
+	// replaying is deterministic
+	var again []StateAccess
+	_, _, err = ReplayCall(table, input, tableAddr, common.Address{}, big.NewInt(0), true, 1000000, evm, func(access StateAccess) {
+		again = append(again, access)
+	})
+	Require(t, err)
+	if len(again) != len(accesses) {
+		Fail(t, "replays made", len(accesses), "and", len(again), "accesses")
+	}
+	for i := range again {
+		if again[i] != accesses[i] {
+			Fail(t, "replays diverged at access", i, again[i], accesses[i])
+		}
+	}
+
+	// replayed writes aren't persisted
+	other := common.BytesToAddress(crypto.Keccak256([]byte{0x76})[:20])
+	input = packTestCall(t, table.Precompile(), "Register", other)
+	_, _, err = ReplayCall(table, input, tableAddr, common.Address{}, big.NewInt(0), false, 1000000, evm, nil)
+	Require(t, err)
+	exists, err := ArbAddressTable{}.AddressExists(testContext(common.Address{}, evm), evm, other)
+	Require(t, err)
+	if exists {
+		Fail(t, "the replayed registration persisted")
+	}
+}
+
+func TestAddressTableDryRunRegister(t *testing.T) {
+	evm := newMockEVMForTesting()
+	precompiles, err := Precompiles()
+	Require(t, err)
+	tableAddr := common.HexToAddress("66")
+	table := precompiles[tableAddr]
+	addr := common.BytesToAddress(crypto.Keccak256([]byte{0x96})[:20])
+	input := packTestCall(t, table.Precompile(), "Register", addr)
+
+	output, _, writes, _, err := DryRunCall(table, input, tableAddr, common.Address{}, big.NewInt(0), 1000000, evm)
+	Require(t, err)
+	if new(big.Int).SetBytes(output).Sign() != 0 {
+		Fail(t, "the dry run registered the address at", output)
+	}
+	if len(writes) == 0 {
+		Fail(t, "no storage writes were recorded")
+	}
+	arbosAccount := common.HexToAddress("0xA4B05FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF")
+	for _, write := range writes {
+		if write.Account != arbosAccount {
+			Fail(t, "registration wrote outside of the ArbOS state", write)
+		}
+	}
+	if _, ok := evm.StateDB.(*recordingStateDB); ok {
+		Fail(t, "the evm's state wasn't restored")
+	}
+
+	// nothing was persisted
+	size, err := ArbAddressTable{}.Size(testContext(common.Address{}, evm), evm)
+	Require(t, err)
+	if size.Sign() != 0 {
+		Fail(t, "the dry run left", size, "addresses registered")
+	}
+	for _, write := range writes {
+		if evm.StateDB.GetState(write.Account, write.Key) == write.Value && write.Value != (common.Hash{}) {
+			Fail(t, "the dry run's write persisted", write)
+		}
+	}
+}
+
 func newMockEVMForTesting() *vm.EVM {
 	return newMockEVMForTestingWithVersion(nil)
 }
 
+// newMockEVMForTestingAtArbosVersion makes a mock evm whose ArbOS state is at the given version
+func newMockEVMForTestingAtArbosVersion(t *testing.T, version uint64) *vm.EVM {
+	t.Helper()
+	evm := newMockEVMForTesting()
+	state, err := arbosState.OpenSystemArbosState(evm.StateDB, nil, false)
+	Require(t, err)
+	state.SetFormatVersion(version)
+	return evm
+}
+
 func newMockEVMForTestingWithVersionAndRunMode(version *uint64, runMode core.MessageRunMode) *vm.EVM {
 	evm := newMockEVMForTestingWithVersion(version)
 	evm.ProcessingHook = arbos.NewTxProcessor(evm, &core.Message{TxRunMode: runMode})
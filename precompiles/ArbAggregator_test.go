@@ -109,3 +109,25 @@ func TestTxBaseFee(t *testing.T) {
 		Fail(t, fee)
 	}
 }
+
+func TestPreferredAggregatorDefaults(t *testing.T) {
+	evm := newMockEVMForTesting()
+	precompiles, err := Precompiles()
+	Require(t, err)
+	aggregatorAddress := common.HexToAddress("6d")
+	aggregator := precompiles[aggregatorAddress].Precompile()
+
+	// every account falls back to the default aggregator, which is the batch poster
+	defaultAggregator, _ := callTestMethod(t, aggregator, evm, "GetDefaultAggregator")[0].(common.Address)
+	if defaultAggregator != l1pricing.BatchPosterAddress {
+		Fail(t, "unexpected default aggregator", defaultAggregator)
+	}
+	for _, account := range []common.Address{{}, common.HexToAddress("0x0a"), l1pricing.BatchPosterAddress} {
+		results := callTestMethod(t, aggregator, evm, "GetPreferredAggregator", account)
+		preferred, _ := results[0].(common.Address)
+		isDefault, _ := results[1].(bool)
+		if preferred != l1pricing.BatchPosterAddress || !isDefault {
+			Fail(t, "account", account, "prefers", preferred, "default:", isDefault)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package precompiles
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+func TestArbDebugHelpers(t *testing.T) {
+	evm := newMockEVMForTesting()
+	precompiles, err := Precompiles()
+	Require(t, err)
+	debugAddr := common.HexToAddress("ff")
+	debug := precompiles[debugAddr].Precompile()
+	caller := common.HexToAddress("0x0118")
+
+	call := func(name string, args ...interface{}) ([]byte, error) {
+		t.Helper()
+		input := packTestCall(t, debug, name, args...)
+		output, _, err := debug.Call(input, debugAddr, debugAddr, caller, big.NewInt(0), false, 1000000, evm)
+		return output, err
+	}
+
+	owners := testContext(common.Address{}, evm).State.ChainOwners()
+	if isOwner, err := owners.IsMember(caller); err != nil || isOwner {
+		Fail(t, "caller shouldn't start as an owner", err)
+	}
+	_, err = call("BecomeChainOwner")
+	Require(t, err)
+	if isOwner, err := owners.IsMember(caller); err != nil || !isOwner {
+		Fail(t, "becomeChainOwner didn't make the caller an owner", err)
+	}
+
+	output, err := call("CustomRevert", uint64(7))
+	if !errors.Is(err, vm.ErrExecutionReverted) {
+		Fail(t, "expected customRevert to revert but got", err)
+	}
+	custom := debug.errors["Custom"].template
+	if !bytes.Equal(output[:4], custom.ID[:4]) {
+		Fail(t, "customRevert didn't return its custom error", output)
+	}
+	values, err := custom.Unpack(output)
+	Require(t, err)
+	if unpacked, _ := values.([]interface{}); len(unpacked) != 3 || unpacked[0] != uint64(7) {
+		Fail(t, "unexpected custom error values", values)
+	}
+
+	output, err = call("LegacyError")
+	if !errors.Is(err, vm.ErrExecutionReverted) || len(output) != 0 {
+		Fail(t, "expected legacyError to revert without data but got", err, output)
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package precompiles
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+func TestFunctionTableIsStubbed(t *testing.T) {
+	evm := newMockEVMForTesting()
+	precompiles, err := Precompiles()
+	Require(t, err)
+	tableAddr := common.HexToAddress("68")
+	table := precompiles[tableAddr].Precompile()
+	caller := common.HexToAddress("0x011d")
+
+	call := func(name string, args ...interface{}) ([]byte, error) {
+		t.Helper()
+		input := packTestCall(t, table, name, args...)
+		output, _, err := table.Call(input, tableAddr, tableAddr, caller, big.NewInt(0), false, 1000000, evm)
+		return output, err
+	}
+
+	// uploads succeed but have no effect
+	_, err = call("Upload", []byte{0x01, 0x02, 0x03})
+	Require(t, err)
+
+	output, err := call("Size", caller)
+	Require(t, err)
+	if new(big.Int).SetBytes(output).Sign() != 0 {
+		Fail(t, "expected an empty table but got size", output)
+	}
+
+	if _, err := call("Get", caller, big.NewInt(0)); !errors.Is(err, vm.ErrExecutionReverted) {
+		Fail(t, "expected reading from the empty table to revert but got", err)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package precompiles
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/offchainlabs/nitro/arbos/storage"
+)
+
+func TestGetPrices(t *testing.T) {
+	evm := newMockEVMForTesting()
+	evm.Context.BaseFee = big.NewInt(150)
+
+	arbState := testContext(common.Address{}, evm).State
+	Require(t, arbState.L1PricingState().SetPricePerUnit(big.NewInt(10)))
+	Require(t, arbState.L2PricingState().SetMinBaseFeeWei(big.NewInt(100)))
+
+	precompiles, err := Precompiles()
+	Require(t, err)
+	gasInfoAddr := common.HexToAddress("6c")
+	gasInfo := precompiles[gasInfoAddr].Precompile()
+
+	call := func(name string) []*big.Int {
+		t.Helper()
+		results := callTestMethod(t, gasInfo, evm, name)
+		prices := make([]*big.Int, len(results))
+		for i, result := range results {
+			prices[i], _ = result.(*big.Int)
+		}
+		return prices
+	}
+	expect := func(name string, prices []*big.Int, expected ...int64) {
+		t.Helper()
+		if len(prices) != len(expected) {
+			Fail(t, name, "returned", len(prices), "values instead of", len(expected))
+		}
+		for i, price := range prices {
+			if price.Int64() != expected[i] {
+				Fail(t, name, "returned", price, "instead of", expected[i], "at position", i)
+			}
+		}
+	}
+
+	weiForL1Calldata := int64(10 * params.TxDataNonZeroGasEIP2028)
+	weiPerL2Tx := weiForL1Calldata * AssumedSimpleTxSize
+	weiForL2Storage := int64(150 * storage.StorageWriteCost)
+	expect("GetPricesInWei", call("GetPricesInWei"), weiPerL2Tx, weiForL1Calldata, weiForL2Storage, 100, 50, 150)
+
+	storageGas := int64(storage.StorageWriteCost)
+	expect("GetPricesInArbGas", call("GetPricesInArbGas"), weiPerL2Tx/150, weiForL1Calldata/150, storageGas)
+}
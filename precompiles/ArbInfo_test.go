@@ -0,0 +1,53 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package precompiles
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/offchainlabs/nitro/util/arbmath"
+)
+
+func TestGetCode(t *testing.T) {
+	evm := newMockEVMForTesting()
+	precompiles, err := Precompiles()
+	Require(t, err)
+	infoAddr := common.HexToAddress("65")
+	info := precompiles[infoAddr].Precompile()
+
+	getCode := func(account common.Address) ([]byte, uint64) {
+		t.Helper()
+		input := packTestCall(t, info, "GetCode", account)
+		output, gasLeft, err := info.Call(input, infoAddr, infoAddr, common.Address{}, big.NewInt(0), false, 1000000, evm)
+		Require(t, err)
+		results, err := info.methodsByName["GetCode"].template.Outputs.Unpack(output)
+		Require(t, err)
+		code, _ := results[0].([]byte)
+		return code, 1000000 - gasLeft
+	}
+
+	empty := common.HexToAddress("0x0111")
+	contract := common.HexToAddress("0x0112")
+	deployed := bytes.Repeat([]byte{0x60, 0x00}, 50)
+	evm.StateDB.SetCode(contract, deployed)
+
+	code, emptyGas := getCode(empty)
+	if len(code) != 0 {
+		Fail(t, "expected no code but got", code)
+	}
+	code, deployedGas := getCode(contract)
+	if !bytes.Equal(code, deployed) {
+		Fail(t, "getCode returned", code, "instead of", deployed)
+	}
+
+	// the code is charged for once when read and again when copied into the result
+	codeWords := arbmath.WordsForBytes(uint64(len(deployed)))
+	if deployedGas-emptyGas != 2*params.CopyGas*codeWords {
+		Fail(t, "getCode's cost didn't scale with the code size", emptyGas, deployedGas)
+	}
+}
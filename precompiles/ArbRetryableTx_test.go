@@ -4,12 +4,16 @@
 package precompiles
 
 import (
+	"errors"
 	"math/big"
 	"testing"
 
+	"github.com/offchainlabs/nitro/arbos/retryables"
 	"github.com/offchainlabs/nitro/arbos/storage"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
 	templates "github.com/offchainlabs/nitro/solgen/go/precompilesgen"
 )
 
@@ -43,8 +47,10 @@ func TestRetryableRedeem(t *testing.T) {
 	redeemCalldata, err := retryABI.Pack("redeem", id)
 	Require(t, err)
 
+	precompiles, err := Precompiles()
+	Require(t, err)
 	retryAddress := common.HexToAddress("6e")
-	_, gasLeft, err := Precompiles()[retryAddress].Call(
+	_, gasLeft, err := precompiles[retryAddress].Call(
 		redeemCalldata,
 		retryAddress,
 		retryAddress,
@@ -63,3 +69,73 @@ func TestRetryableRedeem(t *testing.T) {
 		Fail(t, "didn't consume all the expected gas")
 	}
 }
+
+func TestRetryableUnknownOrExpired(t *testing.T) {
+	evm := newMockEVMForTesting()
+	precompileCtx := testContext(common.Address{}, evm)
+
+	expired := common.BigToHash(big.NewInt(1))
+	to := common.HexToAddress("0x06070809")
+	_, err := precompileCtx.State.RetryableState().CreateRetryable(
+		expired, 100, common.Address{}, &to, common.Big0, common.Address{}, []byte{},
+	)
+	Require(t, err)
+	evm.Context.Time = 101
+
+	retryABI, err := templates.ArbRetryableTxMetaData.GetAbi()
+	Require(t, err)
+	precompiles, err := Precompiles()
+	Require(t, err)
+	retryAddress := common.HexToAddress("6e")
+
+	for _, ticket := range []common.Hash{expired, common.BigToHash(big.NewInt(2))} {
+		for _, method := range []string{"redeem", "getTimeout", "keepalive"} {
+			calldata, err := retryABI.Pack(method, ticket)
+			Require(t, err)
+			_, _, err = precompiles[retryAddress].Call(
+				calldata, retryAddress, retryAddress, common.Address{}, big.NewInt(0), false, 1000000, evm,
+			)
+			if !errors.Is(err, vm.ErrExecutionReverted) {
+				Fail(t, method, "of ticket", ticket, "should revert but got", err)
+			}
+		}
+	}
+}
+
+func TestRetryableKeepalive(t *testing.T) {
+	evm := newMockEVMForTesting()
+	precompileCtx := testContext(common.Address{}, evm)
+
+	id := common.BigToHash(big.NewInt(978645611143))
+	timeout := evm.Context.Time + 100
+	to := common.HexToAddress("0x06070809")
+	_, err := precompileCtx.State.RetryableState().CreateRetryable(
+		id, timeout, common.Address{}, &to, common.Big0, common.Address{}, make([]byte, 42),
+	)
+	Require(t, err)
+
+	precompiles, err := Precompiles()
+	Require(t, err)
+	retryAddress := common.HexToAddress("6e")
+	retry := precompiles[retryAddress].Precompile()
+	if before, _ := callTestMethod(t, retry, evm, "GetTimeout", id)[0].(*big.Int); before.Uint64() != timeout {
+		Fail(t, "unexpected initial timeout", before)
+	}
+
+	extended, _ := callTestMethod(t, retry, evm, "Keepalive", id)[0].(*big.Int)
+	expected := timeout + retryables.RetryableLifetimeSeconds
+	if extended.Uint64() != expected {
+		Fail(t, "keepalive returned", extended, "instead of", expected)
+	}
+	if after, _ := callTestMethod(t, retry, evm, "GetTimeout", id)[0].(*big.Int); after.Cmp(extended) != 0 {
+		Fail(t, "timeout is", after, "after keepalive returned", extended)
+	}
+
+	logs := evm.StateDB.(*state.StateDB).Logs() //nolint:errcheck
+	if len(logs) != 1 || logs[0].Topics[0] != retry.events["LifetimeExtended"].template.ID {
+		Fail(t, "expected a LifetimeExtended event but got", logs)
+	}
+	if logs[0].Topics[1] != id {
+		Fail(t, "the event names the wrong ticket", logs[0].Topics[1])
+	}
+}
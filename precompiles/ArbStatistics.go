@@ -5,22 +5,28 @@ package precompiles
 
 import (
 	"math/big"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
 )
 
 // ArbStatistics provides statistics about the rollup right before the Nitro upgrade.
 // In Classic, this was how a user would get info such as the total number of accounts,
 // but there's now better ways to do that with geth.
 type ArbStatistics struct {
-	Address addr // 0x6e
+	Address addr // 0x6f
 }
 
 // GetStats returns the current block number and some statistics about the rollup's pre-Nitro state
 func (con ArbStatistics) GetStats(c ctx, evm mech) (huge, huge, huge, huge, huge, huge, error) {
 	blockNum := evm.Context.BlockNumber
-	classicNumAccounts := big.NewInt(0)  // TODO: hardcode the final value from Arbitrum Classic
-	classicStorageSum := big.NewInt(0)   // TODO: hardcode the final value from Arbitrum Classic
-	classicGasSum := big.NewInt(0)       // TODO: hardcode the final value from Arbitrum Classic
-	classicNumTxes := big.NewInt(0)      // TODO: hardcode the final value from Arbitrum Classic
-	classicNumContracts := big.NewInt(0) // TODO: hardcode the final value from Arbitrum Classic
-	return blockNum, classicNumAccounts, classicStorageSum, classicGasSum, classicNumTxes, classicNumContracts, nil
+	if c.State.ArbOSVersion() < arbostypes.ArbosVersion_StateBackedStatistics {
+		// older versions report zeros without reading the counters, or charging for the reads
+		zero := big.NewInt(0)
+		return blockNum, zero, zero, zero, zero, zero, nil
+	}
+	numAccounts, storageSum, gasSum, numTxes, numContracts, err := c.State.Statistics().Get()
+	if err != nil {
+		return nil, nil, nil, nil, nil, nil, err
+	}
+	return blockNum, numAccounts, storageSum, gasSum, numTxes, numContracts, nil
 }
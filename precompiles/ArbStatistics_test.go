@@ -0,0 +1,56 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package precompiles
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+)
+
+func TestGetStats(t *testing.T) {
+	evm := newMockEVMForTestingAtArbosVersion(t, arbostypes.ArbosVersion_StateBackedStatistics)
+	evm.Context.BlockNumber = big.NewInt(2048)
+
+	precompiles, err := Precompiles()
+	Require(t, err)
+	statsAddr := common.HexToAddress("6f")
+	stats := precompiles[statsAddr].Precompile()
+
+	// the counters ArbOS keeps, in the order getStats reports them after the block number
+	counters := []*big.Int{big.NewInt(11), big.NewInt(22), big.NewInt(33), big.NewInt(44), big.NewInt(55)}
+	statistics := testContext(common.Address{}, evm).State.Statistics()
+	Require(t, statistics.Set(counters[0], counters[1], counters[2], counters[3], counters[4]))
+
+	call := func() []interface{} {
+		t.Helper()
+		results := callTestMethod(t, stats, evm, "GetStats")
+		if len(results) != 6 {
+			Fail(t, "expected 6 statistics but got", len(results))
+		}
+		if blockNum, _ := results[0].(*big.Int); blockNum.Cmp(evm.Context.BlockNumber) != 0 {
+			Fail(t, "unexpected block number", blockNum)
+		}
+		return results[1:]
+	}
+
+	for i, result := range call() {
+		if counter, _ := result.(*big.Int); counter.Cmp(counters[i]) != 0 {
+			Fail(t, "statistic", i, "is", counter, "instead of", counters[i])
+		}
+	}
+
+	// older versions report zeros regardless of the counters
+	evm = newMockEVMForTestingAtArbosVersion(t, arbostypes.ArbosVersion_StateBackedStatistics-1)
+	evm.Context.BlockNumber = big.NewInt(2048)
+	statistics = testContext(common.Address{}, evm).State.Statistics()
+	Require(t, statistics.Set(counters[0], counters[1], counters[2], counters[3], counters[4]))
+	for i, result := range call() {
+		if counter, _ := result.(*big.Int); counter.Sign() != 0 {
+			Fail(t, "statistic", i, "of an old ArbOS version is", counter)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package precompiles
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/arbos/burn"
+	templates "github.com/offchainlabs/nitro/solgen/go/precompilesgen"
+)
+
+func TestSendTxToL1(t *testing.T) {
+	evm := newMockEVMForTestingWithVersionAndRunMode(nil, core.MessageCommitMode)
+	precompiles, err := Precompiles()
+	Require(t, err)
+	arbSys := precompiles[types.ArbSysAddress].Precompile()
+
+	caller := common.HexToAddress("0x0105")
+	destination := common.HexToAddress("0x0106")
+	input := packTestCall(t, arbSys, "SendTxToL1", destination, []byte{0x01, 0x02, 0x03})
+	output, _, err := arbSys.Call(
+		input, types.ArbSysAddress, types.ArbSysAddress, caller, common.Big0, false, 1000000, evm,
+	)
+	Require(t, err)
+
+	// the leaf was written to the outbox's merkle accumulator
+	leaf := new(big.Int).SetBytes(output)
+	if leaf.Sign() != 0 {
+		Fail(t, "unexpected leaf", leaf)
+	}
+	arbState, err := arbosState.OpenArbosState(evm.StateDB, burn.NewSystemBurner(nil, false))
+	Require(t, err)
+	size, err := arbState.SendMerkleAccumulator().Size()
+	Require(t, err)
+	if size != 1 {
+		Fail(t, "unexpected outbox size", size)
+	}
+
+	// and the send was logged in the same call
+	//nolint:errcheck
+	logs := evm.StateDB.(*state.StateDB).Logs()
+	found := false
+	for _, log := range logs {
+		if log.Address == types.ArbSysAddress && log.Topics[0] == arbSys.events["L2ToL1Tx"].template.ID {
+			found = true
+		}
+	}
+	if !found {
+		Fail(t, "no L2ToL1Tx event was emitted", logs)
+	}
+}
+
+// calldataPricer charges a fixed amount of gas per byte of an L2-to-L1 message's calldata
+type calldataPricer struct {
+	perByte uint64
+}
+
+func (pricer calldataPricer) L1GasCost(method string, calldata []byte) (uint64, error) {
+	if method != "SendTxToL1" {
+		return 0, nil
+	}
+	return pricer.perByte * uint64(len(calldata)), nil
+}
+
+func TestSendTxToL1WithL1Pricing(t *testing.T) {
+	destination := common.HexToAddress("0x0106")
+	send := func(oracle L1PricingOracle) (uint64, int) {
+		t.Helper()
+		evm := newMockEVMForTestingWithVersionAndRunMode(nil, core.MessageCommitMode)
+		precompiles, err := Precompiles()
+		Require(t, err)
+		arbSys := precompiles[types.ArbSysAddress].Precompile()
+		arbSys.SetL1PricingOracle(oracle)
+
+		input := packTestCall(t, arbSys, "SendTxToL1", destination, []byte{0x01, 0x02, 0x03})
+		_, gasLeft, err := arbSys.Call(
+			input, types.ArbSysAddress, types.ArbSysAddress, common.Address{}, common.Big0, false, 1000000, evm,
+		)
+		Require(t, err)
+		return gasLeft, len(input) - 4
+	}
+
+	unpriced, _ := send(nil)
+	priced, calldataSize := send(calldataPricer{perByte: 16})
+	if unpriced-priced != 16*uint64(calldataSize) {
+		Fail(t, "the L1 component charged", unpriced-priced, "gas for", calldataSize, "bytes")
+	}
+}
+
+type precompileTracerMock struct {
+	vm.EVMLogger
+	methods []string
+	gasUsed []uint64
+}
+
+func (tracer *precompileTracerMock) CaptureState(
+	pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error,
+) {
+}
+
+func (tracer *precompileTracerMock) CapturePrecompile(
+	address common.Address, method string, input, output []byte, gasUsed uint64, err error,
+) {
+	tracer.methods = append(tracer.methods, method)
+	tracer.gasUsed = append(tracer.gasUsed, gasUsed)
+}
+
+func TestExportSelectorsJSON(t *testing.T) {
+	exported, err := ExportSelectorsJSON()
+	Require(t, err)
+	var entries []struct {
+		Address    common.Address `json:"address"`
+		Contract   string         `json:"contract"`
+		Method     string         `json:"method"`
+		Selector   string         `json:"selector"`
+		Signature  string         `json:"signature"`
+		Mutability string         `json:"mutability"`
+	}
+	Require(t, json.Unmarshal(exported, &entries))
+
+	precompiles, err := Precompiles()
+	Require(t, err)
+	id := precompiles[types.ArbSysAddress].Precompile().GetMethodID("ArbBlockNumber")
+	expected := hexutil.Encode(id[:])
+	found := false
+	for i, entry := range entries {
+		if i > 0 {
+			previous := entries[i-1]
+			order := bytes.Compare(previous.Address[:], entry.Address[:])
+			if order > 0 || (order == 0 && previous.Selector >= entry.Selector) {
+				Fail(t, "selectors aren't sorted", previous, entry)
+			}
+		}
+		if entry.Contract == "ArbSys" && entry.Method == "arbBlockNumber" {
+			found = true
+			if entry.Selector != expected || entry.Mutability != "view" || entry.Address != types.ArbSysAddress {
+				Fail(t, "arbBlockNumber was exported as", entry)
+			}
+		}
+	}
+	if !found {
+		Fail(t, "arbBlockNumber wasn't exported")
+	}
+}
+
+func TestCallTyped(t *testing.T) {
+	evm := newMockEVMForTesting()
+	precompiles, err := Precompiles()
+	Require(t, err)
+	arbSys := precompiles[types.ArbSysAddress].Precompile()
+
+	input := packTestCall(t, arbSys, "ArbChainID")
+	values, err := arbSys.CallTyped(input, types.ArbSysAddress, types.ArbSysAddress, common.Address{}, common.Big0, true, 1000000, evm)
+	Require(t, err)
+	chainID, ok := values[0].(*big.Int)
+	if !ok || chainID.Cmp(evm.ChainConfig().ChainID) != 0 {
+		Fail(t, "unexpected chain id", values)
+	}
+
+	// reverts are reported as such rather than as decode failures
+	_, err = arbSys.CallTyped([]byte{0xde, 0xad, 0xbe, 0xef}, types.ArbSysAddress, types.ArbSysAddress, common.Address{}, common.Big0, true, 1000000, evm)
+	if !errors.Is(err, vm.ErrExecutionReverted) || errors.Is(err, ErrUndecodableOutput) {
+		Fail(t, "expected a revert but got", err)
+	}
+}
+
+func TestPackCall(t *testing.T) {
+	destination := common.HexToAddress("0x0a")
+	input, err := PackCall(templates.ArbSysMetaData, "withdrawEth", destination)
+	Require(t, err)
+
+	precompiles, err := Precompiles()
+	Require(t, err)
+	arbSys := precompiles[types.ArbSysAddress].Precompile()
+	id := arbSys.GetMethodID("WithdrawEth")
+	if len(input) != 4+32 || *(*bytes4)(input) != id {
+		Fail(t, "unexpected calldata", input)
+	}
+	if common.BytesToAddress(input[4:]) != destination {
+		Fail(t, "unexpected destination in", input)
+	}
+
+	if _, err := PackCall(templates.ArbSysMetaData, "noSuchMethod"); err == nil {
+		Fail(t, "packed a call to a nonexistent method")
+	}
+}
+
+func TestPrecompileTracing(t *testing.T) {
+	evm := newMockEVMForTesting()
+	tracer := &precompileTracerMock{}
+	evm.Config.Tracer = tracer
+
+	precompiles, err := Precompiles()
+	Require(t, err)
+	arbSys := precompiles[types.ArbSysAddress].Precompile()
+
+	input := packTestCall(t, arbSys, "ArbBlockNumber")
+	_, gasLeft, err := arbSys.Call(
+		input, types.ArbSysAddress, types.ArbSysAddress, common.Address{}, common.Big0, false, 1000000, evm,
+	)
+	Require(t, err)
+
+	if len(tracer.methods) != 1 || tracer.methods[0] != "ArbBlockNumber" {
+		Fail(t, "unexpected traced methods", tracer.methods)
+	}
+	if tracer.gasUsed[0] != 1000000-gasLeft {
+		Fail(t, "traced", tracer.gasUsed[0], "gas instead of", 1000000-gasLeft)
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package precompiles
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestBurnArbGas(t *testing.T) {
+	evm := newMockEVMForTesting()
+	precompiles, err := Precompiles()
+	Require(t, err)
+	testAddress := common.HexToAddress("69")
+	arbosTest := precompiles[testAddress].Precompile()
+
+	burn := func(amount int64) uint64 {
+		t.Helper()
+		input := packTestCall(t, arbosTest, "BurnArbGas", big.NewInt(amount))
+		_, gasLeft, err := arbosTest.Call(input, testAddress, testAddress, common.Address{}, big.NewInt(0), false, 1000000, evm)
+		Require(t, err)
+		return gasLeft
+	}
+
+	baseline := burn(0)
+	for _, amount := range []int64{1, 1000, 123456} {
+		if burned := baseline - burn(amount); burned != uint64(amount) {
+			Fail(t, "burning", amount, "gas consumed", burned)
+		}
+	}
+}
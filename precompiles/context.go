@@ -4,17 +4,22 @@
 package precompiles
 
 import (
+	"errors"
 	"math/big"
+	"reflect"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
 
 	"github.com/offchainlabs/nitro/arbos"
 	"github.com/offchainlabs/nitro/arbos/arbosState"
 	"github.com/offchainlabs/nitro/arbos/burn"
+	"github.com/offchainlabs/nitro/arbos/storage"
 	"github.com/offchainlabs/nitro/arbos/util"
+	"github.com/offchainlabs/nitro/util/arbmath"
 )
 
 type addr = common.Address
@@ -24,15 +29,71 @@ type hash = common.Hash
 type bytes4 = [4]byte
 type bytes32 = [32]byte
 type ctx = *Context
+type env = *Environment
 
 type Context struct {
 	caller      addr
+	origin      addr
+	depth       int
+	callvalue   huge
+	valueRefund huge
 	gasSupplied uint64
 	gasLeft     uint64
 	txProcessor *arbos.TxProcessor
 	State       *arbosState.ArbosState
 	tracingInfo *util.TracingInfo
 	readOnly    bool
+	actingAs    addr          // whose storage ReadStorage and WriteStorage access
+	canRead     bool          // whether ReadStorage is permitted, which it isn't for pure methods
+	stateDB     vm.StateDB    // where gas refunds go
+	logs        *[]*types.Log // when set, events are collected here rather than added to the state
+}
+
+// Sender describes who is calling a precompile, and how.
+// Its Depth is the evm's, which geth's hook increments before calling the precompile. So like the depths
+// ArbSys compares, it's 1 when a transaction calls the precompile directly and 2 when that transaction's contract does.
+type Sender struct {
+	Caller addr // the immediate caller
+	Origin addr // the transaction's sender
+	Depth  int  // the evm's call depth, which counts the precompile's own frame
+}
+
+// Environment is the subset of the evm most handlers need, which handlers may take in place of the evm
+type Environment struct {
+	BlockNumber huge
+	Time        uint64
+	Coinbase    addr
+	ChainID     huge
+	StateDB     vm.StateDB
+}
+
+var environmentType = reflect.TypeOf(&Environment{})
+
+// ChainContext is the chain's configuration, which pure handlers may take since it isn't state
+type ChainContext struct {
+	ChainID     huge
+	BlockNumber huge
+	Config      *params.ChainConfig // for checking which forks are active
+}
+
+var chainContextType = reflect.TypeOf(&ChainContext{})
+
+func NewChainContext(evm mech) *ChainContext {
+	return &ChainContext{
+		ChainID:     evm.ChainConfig().ChainID,
+		BlockNumber: evm.Context.BlockNumber,
+		Config:      evm.ChainConfig(),
+	}
+}
+
+func NewEnvironment(evm mech) *Environment {
+	return &Environment{
+		BlockNumber: evm.Context.BlockNumber,
+		Time:        evm.Context.Time,
+		Coinbase:    evm.Context.Coinbase,
+		ChainID:     evm.ChainConfig().ChainID,
+		StateDB:     evm.StateDB,
+	}
 }
 
 func (c *Context) Burn(amount uint64) error {
@@ -49,6 +110,62 @@ func (c *Context) Burned() uint64 {
 	return c.gasSupplied - c.gasLeft
 }
 
+// Refund credits gas to the transaction's refund counter, as the EVM does when an SSTORE clears a slot
+func (c *Context) Refund(gas uint64) {
+	if c.stateDB != nil {
+		c.stateDB.AddRefund(gas)
+	}
+}
+
+// RefundValue returns part of a payable call's value to the caller once the call succeeds
+func (c *Context) RefundValue(amount huge) error {
+	refund := amount
+	if c.valueRefund != nil {
+		refund = arbmath.BigAdd(c.valueRefund, amount)
+	}
+	if amount.Sign() < 0 || c.callvalue == nil || arbmath.BigGreaterThan(refund, c.callvalue) {
+		return errors.New("refund exceeds the call's value")
+	}
+	c.valueRefund = refund
+	return nil
+}
+
+// WithSnapshot runs a multi-step update, rolling back all of its state changes if it fails partway through
+func WithSnapshot(evm mech, update func() error) error {
+	snapshot := evm.StateDB.Snapshot()
+	if err := update(); err != nil {
+		evm.StateDB.RevertToSnapshot(snapshot)
+		return err
+	}
+	return nil
+}
+
+// ReadStorage reads a slot of the account the precompile is acting as. Pure methods may not read state.
+// That account is the precompile itself unless it opted in via SetAllowActingAsStorage, in which case a
+// delegatecalling contract controls the slot, and the value read shouldn't be trusted.
+func (c *Context) ReadStorage(slot hash) (hash, error) {
+	if !c.canRead {
+		return hash{}, errors.New("pure precompile methods may not read storage")
+	}
+	if err := c.Burn(storage.StorageReadCost); err != nil {
+		return hash{}, err
+	}
+	return c.stateDB.GetState(c.actingAs, slot), nil
+}
+
+// WriteStorage writes a slot of the account the precompile is acting as, which only write methods may do.
+// As with ReadStorage, that account is the delegatecalling contract when the precompile allows acting as others.
+func (c *Context) WriteStorage(slot, value hash) error {
+	if c.readOnly {
+		return errors.New("only non-view precompile methods may write storage")
+	}
+	if err := c.Burn(storage.StorageWriteCost); err != nil {
+		return err
+	}
+	c.stateDB.SetState(c.actingAs, slot, value)
+	return nil
+}
+
 func (c *Context) Restrict(err error) {
 	log.Crit("A metered burner was used for access-controlled work", "error", err)
 }
@@ -57,6 +174,10 @@ func (c *Context) HandleError(err error) error {
 	return err
 }
 
+func (c *Context) Sender() Sender {
+	return Sender{Caller: c.caller, Origin: c.origin, Depth: c.depth}
+}
+
 func (c *Context) ReadOnly() bool {
 	return c.readOnly
 }
@@ -69,10 +190,13 @@ func testContext(caller addr, evm mech) *Context {
 	tracingInfo := util.NewTracingInfo(evm, common.Address{}, types.ArbosAddress, util.TracingDuringEVM)
 	ctx := &Context{
 		caller:      caller,
+		origin:      evm.TxContext.Origin,
+		depth:       evm.Depth(),
 		gasSupplied: ^uint64(0),
 		gasLeft:     ^uint64(0),
 		tracingInfo: tracingInfo,
 		readOnly:    false,
+		stateDB:     evm.StateDB,
 	}
 	state, err := arbosState.OpenArbosState(evm.StateDB, burn.NewSystemBurner(tracingInfo, false))
 	if err != nil {
@@ -0,0 +1,118 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package precompiles
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// MockCall is a call made to a MockPrecompile
+type MockCall struct {
+	Input             []byte
+	PrecompileAddress common.Address
+	ActingAs          common.Address
+	Caller            common.Address
+	Value             *big.Int
+	ReadOnly          bool
+	GasSupplied       uint64
+}
+
+// MockResponse is what a MockPrecompile returns for a call
+type MockResponse struct {
+	Output  []byte
+	GasUsed uint64
+	Err     error
+}
+
+// MockPrecompile stands in for a precompile in tests, recording every call and returning programmed responses
+// instead of running any handlers. Install it with the WithOverride option.
+type MockPrecompile struct {
+	mutex     sync.Mutex
+	original  ArbosPrecompile // the precompile being stood in for, if any
+	stub      *Precompile     // what Precompile returns when there's no original
+	calls     []MockCall
+	responses map[bytes4]MockResponse
+	fallback  MockResponse
+}
+
+// NewMockPrecompile mocks the given precompile, which may be nil. Until programmed, calls succeed with no output.
+func NewMockPrecompile(original ArbosPrecompile) *MockPrecompile {
+	return &MockPrecompile{
+		original:  original,
+		stub:      &Precompile{name: "MockPrecompile"},
+		responses: make(map[bytes4]MockResponse),
+	}
+}
+
+// Respond programs the response to calls whose input begins with the selector
+func (mock *MockPrecompile) Respond(selector bytes4, response MockResponse) {
+	mock.mutex.Lock()
+	defer mock.mutex.Unlock()
+	mock.responses[selector] = response
+}
+
+// RespondByDefault programs the response to calls that don't match a selector given to Respond
+func (mock *MockPrecompile) RespondByDefault(response MockResponse) {
+	mock.mutex.Lock()
+	defer mock.mutex.Unlock()
+	mock.fallback = response
+}
+
+// Calls returns the calls made so far, in order
+func (mock *MockPrecompile) Calls() []MockCall {
+	mock.mutex.Lock()
+	defer mock.mutex.Unlock()
+	return append([]MockCall{}, mock.calls...)
+}
+
+func (mock *MockPrecompile) Call(
+	input []byte,
+	precompileAddress common.Address,
+	actingAsAddress common.Address,
+	caller common.Address,
+	value *big.Int,
+	readOnly bool,
+	gasSupplied uint64,
+	evm *vm.EVM,
+) ([]byte, uint64, error) {
+	mock.mutex.Lock()
+	defer mock.mutex.Unlock()
+
+	var recordedValue *big.Int
+	if value != nil {
+		recordedValue = new(big.Int).Set(value)
+	}
+	mock.calls = append(mock.calls, MockCall{
+		Input:             common.CopyBytes(input),
+		PrecompileAddress: precompileAddress,
+		ActingAs:          actingAsAddress,
+		Caller:            caller,
+		Value:             recordedValue,
+		ReadOnly:          readOnly,
+		GasSupplied:       gasSupplied,
+	})
+
+	response := mock.fallback
+	if selector, ok := selectorFromInput(input); ok {
+		if programmed, ok := mock.responses[selector]; ok {
+			response = programmed
+		}
+	}
+	if response.GasUsed > gasSupplied {
+		return nil, 0, vm.ErrOutOfGas
+	}
+	return common.CopyBytes(response.Output), gasSupplied - response.GasUsed, response.Err
+}
+
+// Precompile returns the mocked precompile, or a stub named MockPrecompile without methods if there isn't one
+func (mock *MockPrecompile) Precompile() *Precompile {
+	if mock.original == nil {
+		return mock.stub
+	}
+	return mock.original.Precompile()
+}
@@ -0,0 +1,83 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package precompiles
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+func TestMockPrecompile(t *testing.T) {
+	evm := newMockEVMForTesting()
+	tableAddr := common.HexToAddress("66")
+	standard, err := Precompiles()
+	Require(t, err)
+	mock := NewMockPrecompile(standard[tableAddr])
+	precompiles, err := Precompiles(WithOverride(tableAddr, mock))
+	Require(t, err)
+	table := precompiles[tableAddr]
+	if table != mock || table.Precompile().Name() != "ArbAddressTable" {
+		Fail(t, "the mock wasn't installed in place of ArbAddressTable")
+	}
+
+	sizeID := table.Precompile().GetMethodID("Size")
+	registerID := table.Precompile().GetMethodID("Register")
+	size := common.BigToHash(big.NewInt(5)).Bytes()
+	mock.Respond(sizeID, MockResponse{Output: size, GasUsed: 100})
+	mock.Respond(registerID, MockResponse{Output: revertReason("mocked"), GasUsed: 200, Err: vm.ErrExecutionReverted})
+
+	caller := common.HexToAddress("0xca11e4")
+	output, gasLeft, err := table.Call(sizeID[:], tableAddr, tableAddr, caller, big.NewInt(0), true, 1000, evm)
+	Require(t, err)
+	if !bytes.Equal(output, size) || gasLeft != 900 {
+		Fail(t, "unexpected response", output, gasLeft)
+	}
+
+	addr := common.HexToAddress("0x1234")
+	input := packTestCall(t, table.Precompile(), "Register", addr)
+	output, gasLeft, err = table.Call(input, tableAddr, tableAddr, caller, big.NewInt(0), false, 1000, evm)
+	if !errors.Is(err, vm.ErrExecutionReverted) || !bytes.Equal(output, revertReason("mocked")) || gasLeft != 800 {
+		Fail(t, "unexpected response", output, gasLeft, err)
+	}
+
+	// unprogrammed selectors succeed with no output, unless a default is given
+	if output, _, err := table.Call([]byte{0xde, 0xad}, tableAddr, tableAddr, caller, nil, false, 1000, evm); err != nil || len(output) != 0 {
+		Fail(t, "unexpected default response", output, err)
+	}
+	mock.RespondByDefault(MockResponse{GasUsed: 2000})
+	if _, gasLeft, err := table.Call([]byte{0xde, 0xad}, tableAddr, tableAddr, caller, nil, false, 1000, evm); !errors.Is(err, vm.ErrOutOfGas) || gasLeft != 0 {
+		Fail(t, "a response using more gas than supplied should run out of gas", gasLeft, err)
+	}
+
+	calls := mock.Calls()
+	if len(calls) != 4 {
+		Fail(t, "recorded", len(calls), "calls instead of 4")
+	}
+	if !bytes.Equal(calls[0].Input, sizeID[:]) || !calls[0].ReadOnly || calls[0].Caller != caller {
+		Fail(t, "unexpected first call", calls[0])
+	}
+	if !bytes.Equal(calls[1].Input, input) || calls[1].ReadOnly || calls[1].GasSupplied != 1000 {
+		Fail(t, "unexpected second call", calls[1])
+	}
+	if calls[2].Value != nil {
+		Fail(t, "the call's value wasn't recorded as given", calls[2].Value)
+	}
+
+	// no handler ran, so nothing was registered
+	tableSize, err := ArbAddressTable{}.Size(testContext(common.Address{}, evm), evm)
+	Require(t, err)
+	if tableSize.Sign() != 0 {
+		Fail(t, "the real handler ran", tableSize)
+	}
+
+	// a mock of nothing still describes itself, as the introspection helpers expect
+	if stub := NewMockPrecompile(nil).Precompile(); stub == nil || stub.Name() != "MockPrecompile" || len(stub.Methods()) != 0 {
+		Fail(t, "unexpected stub", stub)
+	}
+}
@@ -4,16 +4,22 @@
 package precompiles
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
 	"reflect"
+	"runtime/debug"
+	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"unicode"
 
 	"github.com/offchainlabs/nitro/arbos"
 	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
 	"github.com/offchainlabs/nitro/arbos/util"
 	templates "github.com/offchainlabs/nitro/solgen/go/precompilesgen"
 	"github.com/offchainlabs/nitro/util/arbmath"
@@ -21,6 +27,7 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -33,7 +40,7 @@ type ArbosPrecompile interface {
 	// Important fields: evm.StateDB and evm.Config.Tracer
 	// NOTE: if precompileAddress != actingAsAddress, watch out!
 	// This is a delegatecall or callcode, so caller might be wrong.
-	// In that case, unless this precompile is pure, it should probably revert.
+	// In that case, unless the precompile allows delegatecalls and is pure, it reverts.
 	Call(
 		input []byte,
 		precompileAddress common.Address,
@@ -48,7 +55,10 @@ type ArbosPrecompile interface {
 	Precompile() *Precompile
 }
 
-type purity uint8
+// Mutability is a precompile method's solidity state mutability
+type Mutability uint8
+
+type purity = Mutability
 
 const (
 	pure purity = iota
@@ -57,15 +67,110 @@ const (
 	payable
 )
 
+const (
+	MutabilityPure       = pure
+	MutabilityView       = view
+	MutabilityNonPayable = write
+	MutabilityPayable    = payable
+)
+
+var mutabilityNames = []string{"pure", "view", "nonpayable", "payable"}
+
+func (m Mutability) String() string {
+	if int(m) < len(mutabilityNames) {
+		return mutabilityNames[m]
+	}
+	return fmt.Sprintf("Mutability(%d)", uint8(m))
+}
+
+// ParseMutability reads a mutability as it appears in a solidity ABI
+func ParseMutability(name string) (Mutability, bool) {
+	for i, known := range mutabilityNames {
+		if name == known {
+			return Mutability(i), true
+		}
+	}
+	return 0, false
+}
+
 type Precompile struct {
-	methods       map[[4]byte]*PrecompileMethod
-	methodsByName map[string]*PrecompileMethod
-	events        map[string]PrecompileEvent
-	errors        map[string]PrecompileError
-	name          string
-	implementer   reflect.Value
-	address       common.Address
-	arbosVersion  uint64
+	methods           map[[4]byte]*PrecompileMethod
+	methodsByName     map[string]*PrecompileMethod
+	events            map[string]PrecompileEvent
+	errors            map[string]PrecompileError
+	name              string
+	implementer       reflect.Value
+	address           common.Address
+	arbosVersion      uint64
+	allowDelegatecall bool       // whether the precompile may be delegatecalled or callcoded
+	actingAsStorage   bool       // whether impure methods may run while acting as another account
+	ownerCheck        OwnerCheck // authorizes callers of owner-only methods
+	fallback          *PrecompileMethod
+	maxInputSize      uint64 // the largest calldata the precompile accepts, or 0 for no limit
+	l1Pricer          L1PricingOracle
+	reentrancyGuard   bool   // when set, rejects calls made while the precompile is already executing
+	gasFloor          uint64 // the minimum charged for any call to one of the precompile's methods
+}
+
+// PrecompileAddresser may be implemented by implementers that provide their address by method rather than by an
+// Address field. The method is preferred when both are present.
+type PrecompileAddresser interface {
+	PrecompileAddress() common.Address
+}
+
+// PrecompileTracer may be implemented by tracers that want to record which precompile methods are called
+type PrecompileTracer interface {
+	CapturePrecompile(address common.Address, method string, input, output []byte, gasUsed uint64, err error)
+}
+
+// L1PricingOracle prices the L1 data a call to a precompile method implies, such as the calldata of an L2-to-L1
+// message. The cost is charged in L2 gas on top of the method's own.
+type L1PricingOracle interface {
+	L1GasCost(method string, calldata []byte) (uint64, error)
+}
+
+// MetricsSink records aggregate statistics about precompile calls
+type MetricsSink interface {
+	RecordCall(contract, method string, gas uint64, reverted bool)
+}
+
+// metricsSink points to the installed sink, if any. Until one is installed, calls record nothing.
+var metricsSink atomic.Pointer[MetricsSink]
+
+// SetMetricsSink installs a sink for precompile call metrics, or removes it when nil.
+// It may be called while transactions execute, though calls already underway keep their sink.
+func SetMetricsSink(sink MetricsSink) {
+	if sink == nil {
+		metricsSink.Store(nil)
+		return
+	}
+	metricsSink.Store(&sink)
+}
+
+// RevertLogger is told why each failed precompile call failed, which helps diagnose integrations
+type RevertLogger interface {
+	LogRevert(contract, method string, caller common.Address, reason error)
+}
+
+// revertLogger points to the installed logger, if any. Until one is installed, failures aren't reported.
+var revertLogger atomic.Pointer[RevertLogger]
+
+// SetRevertLogger installs a logger for the reasons precompile calls fail, or removes it when nil.
+// Like SetMetricsSink, it may be called while transactions execute.
+func SetRevertLogger(logger RevertLogger) {
+	if logger == nil {
+		revertLogger.Store(nil)
+		return
+	}
+	revertLogger.Store(&logger)
+}
+
+// OwnerCheck decides whether the caller may use a precompile's owner-only methods
+type OwnerCheck func(c ctx, evm mech) (bool, error)
+
+// IsChainOwner authorizes the chain's owners
+func IsChainOwner(c ctx, evm mech) (bool, error) {
+	return c.State.ChainOwners().IsMember(c.caller)
 }
 
 type PrecompileMethod struct {
@@ -74,11 +179,23 @@ type PrecompileMethod struct {
 	purity       purity
 	handler      reflect.Method
 	arbosVersion uint64
+	contextArgs  int             // the number of handler args preceding the solidity inputs
+	inputTypes   []reflect.Type  // the handler's types for each solidity input
+	rawOutput    bool            // whether the handler returns its outputs pre-encoded as RawBytes
+	structOutput bool            // whether the handler returns its tuple outputs as the fields of a struct
+	ownerOnly    bool            // whether only callers authorized by the precompile's owner check may call
+	fallback     bool            // whether the handler receives the raw calldata of unmatched selectors
+	usesEnv      bool            // whether the handler takes an Environment instead of the evm
+	usesChain    bool            // whether the pure handler takes a ChainContext
+	gasCost      *reflect.Method // optionally prices or rejects the inputs before the handler runs
+	constantGas  *uint64         // the cost declared by a GasCost that takes no inputs, which needn't be called
+	enumVariants map[int]uint64  // the number of variants of each input that's a solidity enum
 }
 
 type PrecompileEvent struct {
-	name     string
-	template abi.Event
+	name       string
+	template   abi.Event
+	activeFrom *uint64 // the ArbOS version before which the event is silently skipped
 }
 
 type PrecompileError struct {
@@ -91,6 +208,68 @@ type SolError struct {
 	solErr abi.Error
 }
 
+// RawBytes are outputs a handler has already ABI-encoded, which the precompile returns verbatim
+type RawBytes []byte
+
+var rawBytesType = reflect.TypeOf(RawBytes{})
+
+// SolidityError is an error that reverts with ABI-encoded data, such as a solidity custom error.
+// Encode should return the 4-byte error selector followed by the packed arguments.
+type SolidityError interface {
+	error
+	Encode() ([]byte, error)
+}
+
+// Errors for calls the precompile framework rejects before running a method.
+// Each wraps vm.ErrExecutionReverted, so the call still reverts.
+var (
+	ErrDelegatecall    = fmt.Errorf("%w: precompile was delegatecalled", vm.ErrExecutionReverted)
+	ErrNotActingAsSelf = fmt.Errorf("%w: precompile not acting as self", vm.ErrExecutionReverted)
+	ErrReadOnlyCall    = fmt.Errorf("%w: precompile method writes in a read-only call", vm.ErrExecutionReverted)
+	ErrNonPayableValue = fmt.Errorf("%w: value sent to a non-payable precompile method", vm.ErrExecutionReverted)
+)
+
+// the selector of solidity's Error(string), which contracts decode as a revert reason
+var revertSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+
+// versionedRevertReason encodes a revert reason on ArbOS versions whose precompiles explain their reverts.
+// Older versions revert without data, which contracts can observe, so replaying them must do the same.
+func versionedRevertReason(arbosVersion uint64, reason string) []byte {
+	if arbosVersion < arbostypes.ArbosVersion_PrecompileRevertReasons {
+		return nil
+	}
+	return revertReason(reason)
+}
+
+// revertReason ABI-encodes a solidity Error(string) revert payload
+func revertReason(reason string) []byte {
+	words := arbmath.WordsForBytes(uint64(len(reason)))
+	data := make([]byte, 4+64+32*words)
+	copy(data, revertSelector)
+	data[4+31] = 32 // offset to the string's length
+	new(big.Int).SetInt64(int64(len(reason))).FillBytes(data[4+32 : 4+64])
+	copy(data[4+64:], reason)
+	return data
+}
+
+// encodingError reverts with an Error(string) when a precompile can't ABI-encode its logs or outputs
+type encodingError struct {
+	reason string
+	cause  error // the packing error, which versions before 11 return as is
+}
+
+func (e encodingError) Error() string {
+	return e.reason
+}
+
+func (e encodingError) Encode() ([]byte, error) {
+	return revertReason(e.reason), nil
+}
+
+func newEncodingError(what string, err error) encodingError {
+	return encodingError{fmt.Sprintf("ArbOS: failed to encode %v: %v", what, err), err}
+}
+
 func RenderSolError(solErr abi.Error, data []byte) (string, error) {
 	vals, err := solErr.Unpack(data)
 	if err != nil {
@@ -115,25 +294,43 @@ func (e *SolError) Error() string {
 	return rendered
 }
 
+func (e *SolError) Encode() ([]byte, error) {
+	return e.data, nil
+}
+
 // MakePrecompile makes a precompile for the given hardhat-to-geth bindings, ensuring that the implementer
-// supports each method.
-func MakePrecompile(metadata *bind.MetaData, implementer interface{}) (addr, *Precompile) {
-	source, err := abi.JSON(strings.NewReader(metadata.ABI))
+// supports each method. An error describing every problem is returned if it does not.
+func MakePrecompile(metadata *bind.MetaData, implementer interface{}) (addr, *Precompile, error) {
+	return makePrecompile(metadata, implementer, true)
+}
+
+// makePrecompile makes a precompile, calling the constant pricers only when asked since they may rely on the
+// implementer's fields. Callers that only validate the implementer's shape shouldn't ask.
+func makePrecompile(metadata *bind.MetaData, implementer interface{}, priceConstants bool) (addr, *Precompile, error) {
+	// the metadata memoizes its parsed ABI, so repeated constructions skip the JSON decoding
+	parsed, err := metadata.GetAbi()
 	if err != nil {
-		log.Crit("Bad ABI")
+		return addr{}, nil, fmt.Errorf("bad ABI: %w", err)
 	}
+	source := *parsed
 
 	implementerType := reflect.TypeOf(implementer)
 	contract := implementerType.Elem().Name()
 
-	_, ok := implementerType.Elem().FieldByName("Address")
-	if !ok {
-		log.Crit("Implementer for precompile ", contract, " is missing an Address field")
-	}
+	addressed, byMethod := implementer.(PrecompileAddresser)
+	var address addr
+	if byMethod {
+		address = addressed.PrecompileAddress()
+	} else {
+		_, ok := implementerType.Elem().FieldByName("Address")
+		if !ok {
+			return addr{}, nil, fmt.Errorf("implementer for precompile %v is missing an Address field", contract)
+		}
 
-	address, ok := reflect.ValueOf(implementer).Elem().FieldByName("Address").Interface().(addr)
-	if !ok {
-		log.Crit("Implementer for precompile ", contract, "'s Address field has the wrong type")
+		address, ok = reflect.ValueOf(implementer).Elem().FieldByName("Address").Interface().(addr)
+		if !ok {
+			return addr{}, nil, fmt.Errorf("implementer for precompile %v's Address field has the wrong type", contract)
+		}
 	}
 
 	gethAbiFuncTypeEquality := func(actual, geth reflect.Type) bool {
@@ -143,12 +340,12 @@ func MakePrecompile(metadata *bind.MetaData, implementer interface{}) (addr, *Pr
 			return false
 		}
 		for i := 0; i < gethIn; i++ {
-			if !geth.In(i).ConvertibleTo(actual.In(i)) {
+			if !geth.In(i).ConvertibleTo(actual.In(i)) || narrowsInteger(geth.In(i), actual.In(i)) {
 				return false
 			}
 		}
 		for i := 0; i < gethOut; i++ {
-			if !actual.Out(i).ConvertibleTo(geth.Out(i)) {
+			if !outputConvertible(actual.Out(i), geth.Out(i)) {
 				return false
 			}
 		}
@@ -158,16 +355,31 @@ func MakePrecompile(metadata *bind.MetaData, implementer interface{}) (addr, *Pr
 	methods := make(map[[4]byte]*PrecompileMethod)
 	methodsByName := make(map[string]*PrecompileMethod)
 	events := make(map[string]PrecompileEvent)
-	errors := make(map[string]PrecompileError)
+	errorsByName := make(map[string]PrecompileError)
+	var problems []error
+
+	declared := make(map[string]bool) // the go names of the methods in the solidity interface
+	overloads := make(map[string]int)
+	for _, method := range source.Methods {
+		overloads[method.RawName]++
+	}
 
+methods:
 	for _, method := range source.Methods {
 
 		name := method.RawName
 		capitalize := string(unicode.ToUpper(rune(name[0])))
 		name = capitalize + name[1:]
 
+		if overloads[method.RawName] > 1 {
+			// overloaded methods are distinguished by their argument types, as in Method_uint256_address
+			name += overloadSuffix(method.Inputs)
+		}
+		declared[name] = true
+
 		if len(method.ID) != 4 {
-			log.Crit("Method ID isn't 4 bytes")
+			problems = append(problems, fmt.Errorf("precompile %v's method %v has an ID that isn't 4 bytes", contract, name))
+			continue
 		}
 		id := *(*[4]byte)(method.ID)
 
@@ -175,7 +387,8 @@ func MakePrecompile(metadata *bind.MetaData, implementer interface{}) (addr, *Pr
 
 		handler, ok := implementerType.MethodByName(name)
 		if !ok {
-			log.Crit("Precompile " + contract + " must implement " + name)
+			problems = append(problems, fmt.Errorf("precompile %v must implement %v", contract, name))
+			continue
 		}
 
 		var needs = []reflect.Type{
@@ -187,6 +400,10 @@ func MakePrecompile(metadata *bind.MetaData, implementer interface{}) (addr, *Pr
 
 		switch method.StateMutability {
 		case "pure":
+			// pure handlers that don't need the call's context may omit it
+			if handler.Type.NumIn() < 2 || handler.Type.In(1) != reflect.TypeOf((ctx)(nil)) {
+				needs = needs[:1]
+			}
 			purity = pure
 		case "view":
 			needs = append(needs, reflect.TypeOf(&vm.EVM{}))
@@ -199,53 +416,238 @@ func MakePrecompile(metadata *bind.MetaData, implementer interface{}) (addr, *Pr
 			needs = append(needs, reflect.TypeOf(&big.Int{}))
 			purity = payable
 		default:
-			log.Crit("Unknown state mutability ", method.StateMutability)
+			problems = append(problems, fmt.Errorf(
+				"precompile %v's method %v has unknown state mutability %v", contract, name, method.StateMutability,
+			))
+			continue
+		}
+
+		// impure handlers may take the lighter-weight environment in place of the evm
+		usesEnv := purity != pure && handler.Type.NumIn() > 2 && handler.Type.In(2) == environmentType
+		if usesEnv {
+			needs[2] = environmentType
+		}
+
+		// pure handlers may read the chain's configuration, which isn't state, after their context
+		usesChain := purity == pure && len(needs) == 2 && handler.Type.NumIn() > 2 && handler.Type.In(2) == chainContextType
+		if usesChain {
+			needs = append(needs, chainContextType)
 		}
 
+		contextArgs := len(needs)
 		for _, arg := range method.Inputs {
 			needs = append(needs, arg.Type.GetType())
 		}
 
+		// handlers may instead return their outputs already encoded
+		rawOutput := len(method.Outputs) > 0 && handler.Type.NumOut() == 2 && handler.Type.Out(0) == rawBytesType
+
+		// handlers of methods returning tuples may instead return a struct whose fields are the outputs
+		structOutput := !rawOutput && len(method.Outputs) > 1 && handler.Type.NumOut() == 2 &&
+			handler.Type.Out(0).Kind() == reflect.Struct
+
 		var outputs = []reflect.Type{}
-		for _, out := range method.Outputs {
-			outputs = append(outputs, out.Type.GetType())
+		if rawOutput {
+			outputs = append(outputs, rawBytesType)
+		} else if structOutput {
+			result := handler.Type.Out(0)
+			if result.NumField() != len(method.Outputs) {
+				problems = append(problems, fmt.Errorf(
+					"precompile %v's %v returns a struct with %v fields but the method has %v outputs",
+					contract, name, result.NumField(), len(method.Outputs),
+				))
+				continue
+			}
+			for i, out := range method.Outputs {
+				field := result.Field(i)
+				if !field.IsExported() || !field.Type.ConvertibleTo(out.Type.GetType()) {
+					problems = append(problems, fmt.Errorf(
+						"precompile %v's %v returns a struct whose field %v doesn't match output %v of type %v",
+						contract, name, field.Name, i, out.Type.GetType(),
+					))
+					continue methods
+				}
+			}
+			outputs = append(outputs, result)
+		} else {
+			for _, out := range method.Outputs {
+				outputs = append(outputs, out.Type.GetType())
+			}
 		}
 		outputs = append(outputs, reflect.TypeOf((*error)(nil)).Elem())
 
 		expectedHandlerType := reflect.FuncOf(needs, outputs, false)
 
+		// go permits converting slices to arrays, so fixed-size bytes must be checked precisely
+		if !rawOutput && !structOutput && handler.Type.NumOut() == len(outputs) {
+			for i, out := range method.Outputs {
+				if !isFixedBytesType(out.Type, handler.Type.Out(i)) {
+					problems = append(problems, fmt.Errorf(
+						"precompile %v's %v must return output %v as a %v-byte array but returns %v",
+						contract, name, i, out.Type.Size, handler.Type.Out(i),
+					))
+					continue methods
+				}
+			}
+		}
+
 		if !gethAbiFuncTypeEquality(handler.Type, expectedHandlerType) {
-			log.Crit(
-				"Precompile "+contract+"'s "+name+"'s implementer has the wrong type\n",
-				"\texpected:\t", expectedHandlerType, "\n\tbut have:\t", handler.Type,
-			)
+			if purity == pure {
+				withContext := append([]reflect.Type{implementerType, reflect.TypeOf((ctx)(nil))}, needs[contextArgs:]...)
+				withoutContext := append([]reflect.Type{implementerType}, needs[contextArgs:]...)
+				problems = append(problems, fmt.Errorf(
+					"precompile %v's %v's implementer has the wrong type\n\texpected:\t%v\n\tor:\t\t%v\n\tbut have:\t%v",
+					contract, name, reflect.FuncOf(withContext, outputs, false),
+					reflect.FuncOf(withoutContext, outputs, false), handler.Type,
+				))
+				continue
+			}
+			problems = append(problems, fmt.Errorf(
+				"precompile %v's %v's implementer has the wrong type\n\texpected:\t%v\n\tbut have:\t%v",
+				contract, name, expectedHandlerType, handler.Type,
+			))
+			continue
+		}
+
+		inputTypes := make([]reflect.Type, len(method.Inputs))
+		for i := range inputTypes {
+			inputTypes[i] = handler.Type.In(contextArgs + i)
+		}
+
+		// methods whose gas depends on their inputs may price them, or reject them outright, before running,
+		// while methods whose gas doesn't may declare so with a pricer that takes no inputs
+		var gasCost *reflect.Method
+		var constantGas *uint64
+		if pricer, ok := implementerType.MethodByName(name + "GasCost"); ok {
+			pricerResults := []reflect.Type{reflect.TypeOf(uint64(0)), reflect.TypeOf((*error)(nil)).Elem()}
+			expectedPricerType := reflect.FuncOf(append([]reflect.Type{implementerType}, inputTypes...), pricerResults, false)
+			constantPricerType := reflect.FuncOf([]reflect.Type{implementerType}, pricerResults, false)
+			switch pricer.Type {
+			case constantPricerType:
+				// a pricer that ignores the inputs declares a constant cost, which is priced once here
+				if !priceConstants {
+					break
+				}
+				priced, err := callHandler(pricer, []reflect.Value{reflect.ValueOf(implementer)})
+				if err != nil {
+					problems = append(problems, fmt.Errorf("precompile %v's constant %vGasCost failed: %w", contract, name, err))
+					continue
+				}
+				if err, _ := priced[1].Interface().(error); err != nil {
+					problems = append(problems, fmt.Errorf("precompile %v's constant %vGasCost failed: %w", contract, name, err))
+					continue
+				}
+				cost := priced[0].Uint()
+				constantGas = &cost
+			case expectedPricerType:
+				gasCost = &pricer
+			default:
+				problems = append(problems, fmt.Errorf(
+					"precompile %v's %vGasCost has the wrong type\n\texpected:\t%v\n\tbut have:\t%v",
+					contract, name, expectedPricerType, pricer.Type,
+				))
+				continue
+			}
 		}
 
 		method := PrecompileMethod{
-			name,
-			method,
-			purity,
-			handler,
-			0,
+			name:         name,
+			template:     method,
+			purity:       purity,
+			handler:      handler,
+			contextArgs:  contextArgs,
+			inputTypes:   inputTypes,
+			rawOutput:    rawOutput,
+			structOutput: structOutput,
+			usesEnv:      usesEnv,
+			usesChain:    usesChain,
+			gasCost:      gasCost,
+			constantGas:  constantGas,
 		}
 		methods[id] = &method
 		methodsByName[name] = &method
 	}
 
+	// implementers may handle calldata that doesn't match a method via a Fallback
+	var fallback *PrecompileMethod
+	if handler, ok := implementerType.MethodByName("Fallback"); ok {
+		bytesType := reflect.TypeOf([]byte{})
+		expectedHandlerType := reflect.FuncOf(
+			[]reflect.Type{implementerType, reflect.TypeOf((ctx)(nil)), reflect.TypeOf(&vm.EVM{}), bytesType},
+			[]reflect.Type{bytesType, reflect.TypeOf((*error)(nil)).Elem()},
+			false,
+		)
+		if handler.Type != expectedHandlerType {
+			problems = append(problems, fmt.Errorf(
+				"precompile %v's Fallback has the wrong type\n\texpected:\t%v\n\tbut have:\t%v",
+				contract, expectedHandlerType, handler.Type,
+			))
+		} else {
+			fallback = &PrecompileMethod{
+				name:        "Fallback",
+				purity:      write,
+				handler:     handler,
+				contextArgs: 3,
+				rawOutput:   true,
+				fallback:    true,
+			}
+		}
+	}
+
 	for i := 0; i < implementerType.NumMethod(); i++ {
 		method := implementerType.Method(i)
 		name := method.Name
-		if method.IsExported() && methodsByName[name] == nil {
-			log.Crit(contract + " is missing a solidity interface for " + name)
+		isPricer := strings.HasSuffix(name, "GasCost") && declared[strings.TrimSuffix(name, "GasCost")]
+		isAddresser := byMethod && name == "PrecompileAddress"
+		if method.IsExported() && !declared[name] && name != "Fallback" && !isPricer && !isAddresser {
+			problems = append(problems, fmt.Errorf("%v is missing a solidity interface for %v", contract, name))
 		}
 	}
 
+	// since the ABI encodes enums as uint8s, implementers declare their ranges via tags like `enum:"SetMode.mode=3"`
+	for i := 0; i < implementerType.Elem().NumField(); i++ {
+		tag, ok := implementerType.Elem().Field(i).Tag.Lookup("enum")
+		if !ok {
+			continue
+		}
+		target, count, found := strings.Cut(tag, "=")
+		methodName, argName, dotted := strings.Cut(target, ".")
+		variants, err := strconv.ParseUint(count, 10, 64)
+		if !found || !dotted || err != nil || variants == 0 || variants > 256 {
+			problems = append(problems, fmt.Errorf("%v has a malformed enum tag %q", contract, tag))
+			continue
+		}
+		method, ok := methodsByName[methodName]
+		if !ok {
+			problems = append(problems, fmt.Errorf("%v's enum tag %q names an unknown method", contract, tag))
+			continue
+		}
+		arg := -1
+		for j, input := range method.template.Inputs {
+			if input.Name == argName {
+				arg = j
+			}
+		}
+		if arg < 0 || method.template.Inputs[arg].Type.T != abi.UintTy || method.template.Inputs[arg].Type.Size != 8 {
+			problems = append(problems, fmt.Errorf("%v's enum tag %q must name a uint8 input of %v", contract, tag, methodName))
+			continue
+		}
+		if method.enumVariants == nil {
+			method.enumVariants = make(map[int]uint64)
+		}
+		method.enumVariants[arg] = variants
+	}
+
 	// provide the implementer mechanisms to emit logs for the solidity events
 
 	supportedIndices := map[string]struct{}{
 		// the solidity value types: https://docs.soliditylang.org/en/v0.8.9/types.html
 		"address": {},
 		"bool":    {},
+
+		// dynamic types, which are indexed by their hash
+		"string": {},
+		"bytes":  {},
 	}
 	for i := 8; i <= 256; i += 8 {
 		supportedIndices["int"+strconv.Itoa(i)] = struct{}{}
@@ -255,6 +657,7 @@ func MakePrecompile(metadata *bind.MetaData, implementer interface{}) (addr, *Pr
 		supportedIndices["bytes"+strconv.Itoa(i)] = struct{}{}
 	}
 
+events:
 	for _, event := range source.Events {
 		name := event.RawName
 
@@ -267,43 +670,72 @@ func MakePrecompile(metadata *bind.MetaData, implementer interface{}) (addr, *Pr
 
 			if arg.Indexed {
 				_, ok := supportedIndices[arg.Type.String()]
+				if !ok && (arg.Type.T == abi.SliceTy || arg.Type.T == abi.ArrayTy) {
+					// arrays of value types are indexed by the hash of their elements
+					elem := arg.Type.Elem
+					_, ok = supportedIndices[elem.String()]
+					ok = ok && elem.T != abi.StringTy && elem.T != abi.BytesTy
+				}
 				if !ok {
-					log.Crit(
-						"Please change the solidity for precompile ", contract,
-						"'s event ", name, ":\n\tEvent indices of type ",
-						arg.Type.String(), " are not supported",
-					)
+					problems = append(problems, fmt.Errorf(
+						"please change the solidity for precompile %v's event %v:\n\tevent indices of type %v are not supported",
+						contract, name, arg.Type.String(),
+					))
+					continue events
 				}
 			}
 		}
 
+		// logs have at most 4 topics, one of which is the signature unless the event is anonymous
+		maxIndexed := 3
+		if event.Anonymous {
+			maxIndexed = 4
+		}
+		indexed := 0
+		for _, arg := range event.Inputs {
+			if arg.Indexed {
+				indexed++
+			}
+		}
+		if indexed > maxIndexed {
+			problems = append(problems, fmt.Errorf(
+				"precompile %v's event %v has %v indexed inputs but may have at most %v",
+				contract, name, indexed, maxIndexed,
+			))
+			continue
+		}
+
 		uint64Type := reflect.TypeOf(uint64(0))
 		errorType := reflect.TypeOf((*error)(nil)).Elem()
 		expectedFieldType := reflect.FuncOf(needs, []reflect.Type{errorType}, false)
 		expectedCostType := reflect.FuncOf(needs[2:], []reflect.Type{uint64Type, errorType}, false)
 
-		context := "Precompile " + contract + "'s implementer"
+		context := "precompile " + contract + "'s implementer"
 		missing := context + " is missing a field for "
 
 		field, ok := implementerType.Elem().FieldByName(name)
 		if !ok {
-			log.Crit(missing, "event ", name, " of type\n\t", expectedFieldType)
+			problems = append(problems, fmt.Errorf("%vevent %v of type\n\t%v", missing, name, expectedFieldType))
+			continue
 		}
 		costField, ok := implementerType.Elem().FieldByName(name + "GasCost")
 		if !ok {
-			log.Crit(missing, "event ", name, "'s GasCost of type\n\t", expectedCostType)
+			problems = append(problems, fmt.Errorf("%vevent %v's GasCost of type\n\t%v", missing, name, expectedCostType))
+			continue
 		}
 		if !gethAbiFuncTypeEquality(field.Type, expectedFieldType) {
-			log.Crit(
-				context, "'s field for event ", name, " has the wrong type\n",
-				"\texpected:\t", expectedFieldType, "\n\tbut have:\t", field.Type,
-			)
+			problems = append(problems, fmt.Errorf(
+				"%v's field for event %v has the wrong type\n\texpected:\t%v\n\tbut have:\t%v",
+				context, name, expectedFieldType, field.Type,
+			))
+			continue
 		}
 		if !gethAbiFuncTypeEquality(costField.Type, expectedCostType) {
-			log.Crit(
-				context, "'s field for event ", name, "GasCost has the wrong type\n",
-				"\texpected:\t", expectedCostType, "\n\tbut have:\t", costField.Type,
-			)
+			problems = append(problems, fmt.Errorf(
+				"%v's field for event %vGasCost has the wrong type\n\texpected:\t%v\n\tbut have:\t%v",
+				context, name, expectedCostType, costField.Type,
+			))
+			continue
 		}
 
 		structFields := reflect.ValueOf(implementer).Elem()
@@ -323,32 +755,46 @@ func MakePrecompile(metadata *bind.MetaData, implementer interface{}) (addr, *Pr
 
 		// we can't capture `event` since the for loop will change its value
 		capturedEvent := event
+		activeFrom := new(uint64)
 		nilError := reflect.Zero(reflect.TypeOf((*error)(nil)).Elem())
 
-		gascost := func(args []reflect.Value) []reflect.Value {
-
-			cost := params.LogGas
-			cost += params.LogTopicGas * uint64(1+len(topicInputs))
-
-			var dataValues []interface{}
-
+		// filter by index'd into data and topics. Indexed values, even if ultimately hashed,
+		// aren't supposed to have their contents stored in the general-purpose data portion.
+		splitArgs := func(args []reflect.Value) ([]interface{}, []interface{}) {
+			dataValues := make([]interface{}, 0, len(dataInputs))
+			topicValues := make([]interface{}, 0, len(topicInputs))
 			for i := 0; i < len(args); i++ {
-				if !capturedEvent.Inputs[i].Indexed {
+				if capturedEvent.Inputs[i].Indexed {
+					topicValues = append(topicValues, args[i].Interface())
+				} else {
 					dataValues = append(dataValues, args[i].Interface())
 				}
 			}
+			return dataValues, topicValues
+		}
+
+		logCost := func(data []byte) uint64 {
+			topicCount := uint64(len(topicInputs))
+			if !capturedEvent.Anonymous {
+				topicCount++ // the signature hash is the first topic
+			}
+			return gasSum(
+				params.LogGas,
+				arbmath.SaturatingUMul(params.LogTopicGas, topicCount),
+				arbmath.SaturatingUMul(params.LogDataGas, uint64(len(data))), // charge for the number of bytes
+			)
+		}
 
+		gascost := func(args []reflect.Value) []reflect.Value {
+			dataValues, _ := splitArgs(args)
 			data, err := dataInputs.PackValues(dataValues)
 			if err != nil {
 				glog.Error(fmt.Sprintf(
 					"Could not pack values for event %s's GasCost\nerror %s", name, err,
 				))
-				return []reflect.Value{reflect.ValueOf(0), reflect.ValueOf(err)}
+				return []reflect.Value{reflect.ValueOf(uint64(0)), reflect.ValueOf(newEncodingError("event "+name, err))}
 			}
-
-			// charge for the number of bytes
-			cost += params.LogDataGas * uint64(len(data))
-			return []reflect.Value{reflect.ValueOf(cost), nilError}
+			return []reflect.Value{reflect.ValueOf(logCost(data)), nilError}
 		}
 
 		emit := func(args []reflect.Value) []reflect.Value {
@@ -358,69 +804,45 @@ func MakePrecompile(metadata *bind.MetaData, implementer interface{}) (addr, *Pr
 			state := evm.StateDB
 			args = args[2:]
 
+			// pure & view methods can't persist logs, though they may emit them into a caller's buffer
 			version := arbosState.ArbOSVersion(state)
-			if callerCtx.readOnly && version >= 11 {
-				return []reflect.Value{reflect.ValueOf(vm.ErrWriteProtection)}
-			}
-
-			emitCost := gascost(args)
-			cost := emitCost[0].Interface().(uint64) //nolint:errcheck
-			if !emitCost[1].IsNil() {
-				// an error occurred during gascost()
-				return []reflect.Value{emitCost[1]}
-			}
-			if err := callerCtx.Burn(cost); err != nil {
-				// the user has run out of gas
-				return []reflect.Value{reflect.ValueOf(vm.ErrOutOfGas)}
+			if version < *activeFrom {
+				// the event hasn't yet been activated
+				return []reflect.Value{nilError}
 			}
-
-			// Filter by index'd into data and topics. Indexed values, even if ultimately hashed,
-			// aren't supposed to have their contents stored in the general-purpose data portion.
-			var dataValues []interface{}
-			var topicValues []interface{}
-
-			for i := 0; i < len(args); i++ {
-				if capturedEvent.Inputs[i].Indexed {
-					topicValues = append(topicValues, args[i].Interface())
-				} else {
-					dataValues = append(dataValues, args[i].Interface())
-				}
+			if callerCtx.readOnly && version >= 11 && callerCtx.logs == nil {
+				return []reflect.Value{reflect.ValueOf(vm.ErrWriteProtection)}
 			}
 
+			// the data is packed once, both to price the log and to include in it
+			dataValues, topicValues := splitArgs(args)
 			data, err := dataInputs.PackValues(dataValues)
 			if err != nil {
 				glog.Error(fmt.Sprintf(
 					"Couldn't pack values for event %s\nnargs %s\nvalues %s\ntopics %s\nerror %s",
 					name, args, dataValues, topicValues, err,
 				))
-				return []reflect.Value{reflect.ValueOf(err)}
+				return []reflect.Value{reflect.ValueOf(newEncodingError("event "+name, err))}
+			}
+			if err := callerCtx.Burn(logCost(data)); err != nil {
+				// the user has run out of gas
+				return []reflect.Value{reflect.ValueOf(vm.ErrOutOfGas)}
 			}
 
-			topics := []common.Hash{capturedEvent.ID}
+			topics := []common.Hash{}
+			if !capturedEvent.Anonymous {
+				topics = append(topics, capturedEvent.ID)
+			}
 
 			for i, input := range topicInputs {
-				// Geth provides infrastructure for packing arrays of values,
-				// so we create an array with just the value we want to pack.
-
-				packable := []interface{}{topicValues[i]}
-				bytes, err := abi.Arguments{input}.PackValues(packable)
+				topic, err := eventTopic(input, topicValues[i])
 				if err != nil {
 					glog.Error(fmt.Sprintf(
 						"Packing error for event %s\nargs %s\nvalues %s\ntopics %s\nerror %s",
 						name, args, dataValues, topicValues, err,
 					))
-					return []reflect.Value{reflect.ValueOf(err)}
+					return []reflect.Value{reflect.ValueOf(newEncodingError("event "+name, err))}
 				}
-
-				var topic [32]byte
-
-				if len(bytes) > 32 {
-					topic = *(*[32]byte)(crypto.Keccak256(bytes))
-				} else {
-					offset := 32 - len(bytes)
-					copy(topic[offset:], bytes)
-				}
-
 				topics = append(topics, topic)
 			}
 
@@ -433,6 +855,12 @@ func MakePrecompile(metadata *bind.MetaData, implementer interface{}) (addr, *Pr
 				//   TxHash, TxIndex, Index, and Removed
 			}
 
+			if callerCtx.logs != nil {
+				// this is a simulation, so collect the log rather than persisting it
+				*callerCtx.logs = append(*callerCtx.logs, event)
+				return []reflect.Value{nilError}
+			}
+			// the statedb journals logs, so those emitted by a call that reverts are discarded with the snapshot
 			state.AddLog(event)
 			return []reflect.Value{nilError}
 		}
@@ -443,6 +871,7 @@ func MakePrecompile(metadata *bind.MetaData, implementer interface{}) (addr, *Pr
 		events[name] = PrecompileEvent{
 			name,
 			event,
+			activeFrom,
 		}
 	}
 
@@ -457,18 +886,20 @@ func MakePrecompile(metadata *bind.MetaData, implementer interface{}) (addr, *Pr
 		errorType := reflect.TypeOf((*error)(nil)).Elem()
 		expectedFieldType := reflect.FuncOf(needs, []reflect.Type{errorType}, false)
 
-		context := "Precompile " + contract + "'s implementer"
+		context := "precompile " + contract + "'s implementer"
 		missing := context + " is missing a field for "
 
 		field, ok := implementerType.Elem().FieldByName(name + "Error")
 		if !ok {
-			log.Crit(missing, "custom error ", name, "Error of type\n\t", expectedFieldType)
+			problems = append(problems, fmt.Errorf("%vcustom error %vError of type\n\t%v", missing, name, expectedFieldType))
+			continue
 		}
 		if field.Type != expectedFieldType {
-			log.Crit(
-				context, "'s field for error ", name, "Error has the wrong type\n",
-				"\texpected:\t", expectedFieldType, "\n\tbut have:\t", field.Type,
-			)
+			problems = append(problems, fmt.Errorf(
+				"%v's field for error %vError has the wrong type\n\texpected:\t%v\n\tbut have:\t%v",
+				context, name, expectedFieldType, field.Type,
+			))
+			continue
 		}
 
 		structFields := reflect.ValueOf(implementer).Elem()
@@ -497,31 +928,270 @@ func MakePrecompile(metadata *bind.MetaData, implementer interface{}) (addr, *Pr
 
 		errorReturnPointer.Set(reflect.MakeFunc(field.Type, errorReturn))
 
-		errors[name] = PrecompileError{
+		errorsByName[name] = PrecompileError{
 			name,
 			solErr,
 		}
 	}
 
+	if len(problems) > 0 {
+		return addr{}, nil, errors.Join(problems...)
+	}
+
 	return address, &Precompile{
-		methods,
-		methodsByName,
-		events,
-		errors,
-		contract,
-		reflect.ValueOf(implementer),
-		address,
-		0,
+		methods:       methods,
+		methodsByName: methodsByName,
+		events:        events,
+		errors:        errorsByName,
+		name:          contract,
+		implementer:   reflect.ValueOf(implementer),
+		address:       address,
+		fallback:      fallback,
+	}, nil
+}
+
+// isFixedBytesType checks that a handler's type for a fixed-size bytes value is an array of that size,
+// which is trivially true for any other kind of value. External function values, an address followed by a
+// selector, are 24 fixed bytes too.
+func isFixedBytesType(abiType abi.Type, actual reflect.Type) bool {
+	if abiType.T != abi.FixedBytesTy && abiType.T != abi.FunctionTy {
+		return true
+	}
+	return actual.Kind() == reflect.Array && actual.Len() == abiType.Size && actual.Elem().Kind() == reflect.Uint8
+}
+
+// narrowsInteger checks whether converting between integer types would truncate. Geth decodes uintN and intN
+// inputs as the native go integer of that width when N is 8, 16, 32, or 64, and as a *big.Int otherwise.
+func narrowsInteger(from, to reflect.Type) bool {
+	isInteger := func(kind reflect.Kind) bool {
+		return (kind >= reflect.Int && kind <= reflect.Int64) || (kind >= reflect.Uint && kind <= reflect.Uint64)
+	}
+	return isInteger(from.Kind()) && isInteger(to.Kind()) && to.Bits() < from.Bits()
+}
+
+// outputConvertible checks whether normalizeOutput can turn a handler's output into the type geth expects
+func outputConvertible(actual, expected reflect.Type) bool {
+	if actual.ConvertibleTo(expected) {
+		return true
+	}
+	switch {
+	case actual.Kind() == reflect.Slice && expected.Kind() == reflect.Slice:
+		return outputConvertible(actual.Elem(), expected.Elem())
+	case actual.Kind() == reflect.Array && expected.Kind() == reflect.Array:
+		return actual.Len() == expected.Len() && outputConvertible(actual.Elem(), expected.Elem())
+	}
+	return false
+}
+
+// normalizeOutput converts an output to the exact go type geth expects, such as a common.Hash to a [32]byte
+func normalizeOutput(output abi.Argument, value reflect.Value) interface{} {
+	return convertOutput(value, output.Type.GetType()).Interface()
+}
+
+// convertOutput converts a value to the given type, element by element for slices and arrays like [][20]byte
+func convertOutput(value reflect.Value, expected reflect.Type) reflect.Value {
+	if value.Type() == expected {
+		return value
+	}
+	var converted reflect.Value
+	switch {
+	case value.Kind() == expected.Kind() && value.Type().ConvertibleTo(expected):
+		return value.Convert(expected)
+	case value.Kind() == reflect.Slice && expected.Kind() == reflect.Slice:
+		converted = reflect.MakeSlice(expected, value.Len(), value.Len())
+	case value.Kind() == reflect.Array && expected.Kind() == reflect.Array && value.Len() == expected.Len():
+		converted = reflect.New(expected).Elem()
+	default:
+		return value
+	}
+	for i := 0; i < value.Len(); i++ {
+		converted.Index(i).Set(convertOutput(value.Index(i), expected.Elem()))
+	}
+	return converted
+}
+
+// checkOutputWidth ensures big.Int outputs fit their declared type, since geth's encoder silently truncates them
+func checkOutputWidth(abiType abi.Type, value interface{}) error {
+	switch abiType.T {
+	case abi.UintTy, abi.IntTy:
+		number, ok := value.(*big.Int)
+		if !ok || number == nil {
+			return nil
+		}
+		fits := false
+		if abiType.T == abi.UintTy {
+			fits = number.Sign() >= 0 && number.BitLen() <= abiType.Size
+		} else if number.Sign() >= 0 {
+			fits = number.BitLen() < abiType.Size
+		} else {
+			// the magnitude may reach 2^(N-1), the width's most negative value
+			magnitude := new(big.Int).Neg(number)
+			fits = magnitude.BitLen() < abiType.Size || (magnitude.BitLen() == abiType.Size && magnitude.TrailingZeroBits() == uint(abiType.Size-1))
+		}
+		if !fits {
+			return fmt.Errorf("output overflows %v", abiType.String())
+		}
+	case abi.SliceTy, abi.ArrayTy:
+		list := reflect.ValueOf(value)
+		if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+			return nil
+		}
+		for i := 0; i < list.Len(); i++ {
+			if err := checkOutputWidth(*abiType.Elem, list.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// gasSum totals gas costs, saturating rather than wrapping so that an overflow can only cause an out-of-gas revert
+func gasSum(components ...uint64) uint64 {
+	total := uint64(0)
+	for _, component := range components {
+		total = arbmath.SaturatingUAdd(total, component)
+	}
+	return total
+}
+
+// copyCost prices copying data in or out of a precompile
+func copyCost(data []byte) uint64 {
+	return arbmath.SaturatingUMul(params.CopyGas, arbmath.WordsForBytes(uint64(len(data))))
+}
+
+// overloadSuffix names an overloaded method's handler by its argument types
+func overloadSuffix(inputs abi.Arguments) string {
+	sanitize := strings.NewReplacer("[", "Array", "]", "", "(", "", ")", "", ",", "")
+	suffix := ""
+	for _, input := range inputs {
+		suffix += "_" + sanitize.Replace(input.Type.String())
 	}
+	return suffix
+}
+
+// callHandler invokes a method's handler or pricer, recovering from any panic so that the node doesn't crash
+func callHandler(handler reflect.Method, args []reflect.Value) (results []reflect.Value, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			log.Error("recovered from precompile panic", "method", handler.Name, "stack", string(debug.Stack()))
+			err = fmt.Errorf("%v panicked: %v", handler.Name, recovered)
+		}
+	}()
+	return handler.Func.Call(args), nil
 }
 
-func Precompiles() map[addr]ArbosPrecompile {
+// eventTopic encodes an indexed event arg as a log topic
+func eventTopic(input abi.Argument, value interface{}) (common.Hash, error) {
+	// Solidity indexes dynamic types by the hash of their contents
+	switch input.Type.T {
+	case abi.StringTy:
+		return crypto.Keccak256Hash([]byte(reflect.ValueOf(value).String())), nil
+	case abi.BytesTy:
+		return crypto.Keccak256Hash(reflect.ValueOf(value).Bytes()), nil
+	case abi.SliceTy, abi.ArrayTy:
+		// arrays are hashed as their padded elements without a length prefix
+		elems := reflect.ValueOf(value)
+		elemArgs := abi.Arguments{{Type: *input.Type.Elem}}
+		var encoded []byte
+		for i := 0; i < elems.Len(); i++ {
+			packed, err := elemArgs.PackValues([]interface{}{elems.Index(i).Interface()})
+			if err != nil {
+				return common.Hash{}, err
+			}
+			encoded = append(encoded, packed...)
+		}
+		return crypto.Keccak256Hash(encoded), nil
+	}
+
+	// Geth provides infrastructure for packing arrays of values,
+	// so we create an array with just the value we want to pack.
+	packed, err := abi.Arguments{input}.PackValues([]interface{}{value})
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if len(packed) > 32 {
+		return crypto.Keccak256Hash(packed), nil
+	}
+	var topic common.Hash
+	copy(topic[32-len(packed):], packed)
+	return topic, nil
+}
+
+// PrecompileOption customizes the set of precompiles a chain registers
+type PrecompileOption func(contracts map[addr]ArbosPrecompile)
+
+// WithoutPrecompile leaves out the precompile at the given address
+func WithoutPrecompile(address addr) PrecompileOption {
+	return func(contracts map[addr]ArbosPrecompile) {
+		delete(contracts, address)
+	}
+}
+
+// WithOverride registers a custom implementation at the given address, replacing any standard precompile there.
+// Note that ArbOS's own hooks, such as those emitting ArbSys's and ArbRetryableTx's events, still use the originals.
+func WithOverride(address addr, impl ArbosPrecompile) PrecompileOption {
+	return func(contracts map[addr]ArbosPrecompile) {
+		contracts[address] = impl
+	}
+}
+
+// WithGasFloor charges every call to a method of the registered precompiles the given base cost, on top of the
+// method's own. Calls that don't match a method aren't charged it.
+func WithGasFloor(gas uint64) PrecompileOption {
+	return func(contracts map[addr]ArbosPrecompile) {
+		for _, contract := range contracts {
+			if precompile := contract.Precompile(); precompile != nil {
+				precompile.SetGasFloor(gas)
+			}
+		}
+	}
+}
+
+// Precompiles builds the full set of ArbOS precompiles, returning an error describing
+// every implementer that failed to match its solidity interface.
+func Precompiles(opts ...PrecompileOption) (map[addr]ArbosPrecompile, error) {
+	return makePrecompiles(true, opts...)
+}
+
+// makePrecompiles builds the precompiles, installing the hooks ArbOS uses to emit their events when asked.
+// Callers that only inspect the precompiles shouldn't install them, since that races with block processing.
+func makePrecompiles(installHooks bool, opts ...PrecompileOption) (map[addr]ArbosPrecompile, error) {
 
 	//nolint:gocritic
 	hex := func(s string) addr {
 		return common.HexToAddress(s)
 	}
 
+	var errs []error
+	build := func(metadata *bind.MetaData, implementer interface{}) *Precompile {
+		_, precompile, err := MakePrecompile(metadata, implementer)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		return precompile
+	}
+
+	ArbInfo := build(templates.ArbInfoMetaData, &ArbInfo{Address: hex("65")})
+	ArbAddressTable := build(templates.ArbAddressTableMetaData, &ArbAddressTable{Address: hex("66")})
+	ArbBLS := build(templates.ArbBLSMetaData, &ArbBLS{Address: hex("67")})
+	ArbFunctionTable := build(templates.ArbFunctionTableMetaData, &ArbFunctionTable{Address: hex("68")})
+	ArbosTest := build(templates.ArbosTestMetaData, &ArbosTest{Address: hex("69")})
+	ArbGasInfo := build(templates.ArbGasInfoMetaData, &ArbGasInfo{Address: hex("6c")})
+	ArbAggregator := build(templates.ArbAggregatorMetaData, &ArbAggregator{Address: hex("6d")})
+	ArbStatistics := build(templates.ArbStatisticsMetaData, &ArbStatistics{Address: hex("6f")})
+	ArbOwnerPublic := build(templates.ArbOwnerPublicMetaData, &ArbOwnerPublic{Address: hex("6b")})
+	ArbRetryableImpl := &ArbRetryableTx{Address: types.ArbRetryableTxAddress}
+	ArbRetryable := build(templates.ArbRetryableTxMetaData, ArbRetryableImpl)
+	ArbSys := build(templates.ArbSysMetaData, &ArbSys{Address: types.ArbSysAddress})
+	ArbOwnerImpl := &ArbOwner{Address: hex("70")}
+	ArbOwner := build(templates.ArbOwnerMetaData, ArbOwnerImpl)
+	ArbDebug := build(templates.ArbDebugMetaData, &ArbDebug{Address: hex("ff")})
+	ArbosActs := build(templates.ArbosActsMetaData, &ArbosActs{Address: types.ArbosAddress})
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("failed to make precompiles: %w", err)
+	}
+
 	contracts := make(map[addr]ArbosPrecompile)
 
 	insert := func(address addr, impl ArbosPrecompile) *Precompile {
@@ -529,17 +1199,17 @@ func Precompiles() map[addr]ArbosPrecompile {
 		return impl.Precompile()
 	}
 
-	insert(MakePrecompile(templates.ArbInfoMetaData, &ArbInfo{Address: hex("65")}))
-	insert(MakePrecompile(templates.ArbAddressTableMetaData, &ArbAddressTable{Address: hex("66")}))
-	insert(MakePrecompile(templates.ArbBLSMetaData, &ArbBLS{Address: hex("67")}))
-	insert(MakePrecompile(templates.ArbFunctionTableMetaData, &ArbFunctionTable{Address: hex("68")}))
-	insert(MakePrecompile(templates.ArbosTestMetaData, &ArbosTest{Address: hex("69")}))
-	ArbGasInfo := insert(MakePrecompile(templates.ArbGasInfoMetaData, &ArbGasInfo{Address: hex("6c")}))
+	insert(ArbInfo.address, ArbInfo)
+	insert(ArbAddressTable.address, ArbAddressTable)
+	insert(ArbBLS.address, ArbBLS)
+	insert(ArbFunctionTable.address, ArbFunctionTable)
+	insert(ArbosTest.address, ArbosTest)
+	insert(ArbGasInfo.address, ArbGasInfo)
 	ArbGasInfo.methodsByName["GetL1FeesAvailable"].arbosVersion = 10
 	ArbGasInfo.methodsByName["GetL1RewardRate"].arbosVersion = 11
 	ArbGasInfo.methodsByName["GetL1RewardRecipient"].arbosVersion = 11
-	insert(MakePrecompile(templates.ArbAggregatorMetaData, &ArbAggregator{Address: hex("6d")}))
-	insert(MakePrecompile(templates.ArbStatisticsMetaData, &ArbStatistics{Address: hex("6f")}))
+	insert(ArbAggregator.address, ArbAggregator)
+	insert(ArbStatistics.address, ArbStatistics)
 
 	eventCtx := func(gasLimit uint64, err error) *Context {
 		if err != nil {
@@ -551,55 +1221,175 @@ func Precompiles() map[addr]ArbosPrecompile {
 		}
 	}
 
-	ArbOwnerPublic := insert(MakePrecompile(templates.ArbOwnerPublicMetaData, &ArbOwnerPublic{Address: hex("6b")}))
+	insert(ArbOwnerPublic.address, ArbOwnerPublic)
 	ArbOwnerPublic.methodsByName["GetInfraFeeAccount"].arbosVersion = 5
 	ArbOwnerPublic.methodsByName["RectifyChainOwner"].arbosVersion = 11
 	ArbOwnerPublic.methodsByName["GetBrotliCompressionLevel"].arbosVersion = 20
 
-	ArbRetryableImpl := &ArbRetryableTx{Address: types.ArbRetryableTxAddress}
-	ArbRetryable := insert(MakePrecompile(templates.ArbRetryableTxMetaData, ArbRetryableImpl))
-	arbos.ArbRetryableTxAddress = ArbRetryable.address
-	arbos.RedeemScheduledEventID = ArbRetryable.events["RedeemScheduled"].template.ID
-	arbos.EmitReedeemScheduledEvent = func(
-		evm mech, gas, nonce uint64, ticketId, retryTxHash bytes32,
-		donor addr, maxRefund *big.Int, submissionFeeRefund *big.Int,
-	) error {
-		zero := common.Big0
-		context := eventCtx(ArbRetryableImpl.RedeemScheduledGasCost(hash{}, hash{}, 0, 0, addr{}, zero, zero))
-		return ArbRetryableImpl.RedeemScheduled(
-			context, evm, ticketId, retryTxHash, nonce, gas, donor, maxRefund, submissionFeeRefund,
-		)
-	}
-	arbos.EmitTicketCreatedEvent = func(evm mech, ticketId bytes32) error {
-		context := eventCtx(ArbRetryableImpl.TicketCreatedGasCost(hash{}))
-		return ArbRetryableImpl.TicketCreated(context, evm, ticketId)
+	insert(ArbRetryable.address, ArbRetryable)
+	if installHooks {
+		arbos.ArbRetryableTxAddress = ArbRetryable.address
+		arbos.RedeemScheduledEventID = ArbRetryable.events["RedeemScheduled"].template.ID
+		arbos.EmitReedeemScheduledEvent = func(
+			evm mech, gas, nonce uint64, ticketId, retryTxHash bytes32,
+			donor addr, maxRefund *big.Int, submissionFeeRefund *big.Int,
+		) error {
+			zero := common.Big0
+			context := eventCtx(ArbRetryableImpl.RedeemScheduledGasCost(hash{}, hash{}, 0, 0, addr{}, zero, zero))
+			return ArbRetryableImpl.RedeemScheduled(
+				context, evm, ticketId, retryTxHash, nonce, gas, donor, maxRefund, submissionFeeRefund,
+			)
+		}
+		arbos.EmitTicketCreatedEvent = func(evm mech, ticketId bytes32) error {
+			context := eventCtx(ArbRetryableImpl.TicketCreatedGasCost(hash{}))
+			return ArbRetryableImpl.TicketCreated(context, evm, ticketId)
+		}
 	}
 
-	ArbSys := insert(MakePrecompile(templates.ArbSysMetaData, &ArbSys{Address: types.ArbSysAddress}))
-	arbos.ArbSysAddress = ArbSys.address
-	arbos.L2ToL1TransactionEventID = ArbSys.events["L2ToL1Transaction"].template.ID
-	arbos.L2ToL1TxEventID = ArbSys.events["L2ToL1Tx"].template.ID
+	insert(ArbSys.address, ArbSys)
+	if installHooks {
+		arbos.ArbSysAddress = ArbSys.address
+		arbos.L2ToL1TransactionEventID = ArbSys.events["L2ToL1Transaction"].template.ID
+		arbos.L2ToL1TxEventID = ArbSys.events["L2ToL1Tx"].template.ID
+	}
 
-	ArbOwnerImpl := &ArbOwner{Address: hex("70")}
 	emitOwnerActs := func(evm mech, method bytes4, owner addr, data []byte) error {
 		context := eventCtx(ArbOwnerImpl.OwnerActsGasCost(method, owner, data))
 		return ArbOwnerImpl.OwnerActs(context, evm, method, owner, data)
 	}
-	_, ArbOwner := MakePrecompile(templates.ArbOwnerMetaData, ArbOwnerImpl)
 	ArbOwner.methodsByName["GetInfraFeeAccount"].arbosVersion = 5
 	ArbOwner.methodsByName["SetInfraFeeAccount"].arbosVersion = 5
 	ArbOwner.methodsByName["ReleaseL1PricerSurplusFunds"].arbosVersion = 10
 	ArbOwner.methodsByName["SetChainConfig"].arbosVersion = 11
 	ArbOwner.methodsByName["SetBrotliCompressionLevel"].arbosVersion = 20
 
-	insert(ownerOnly(ArbOwnerImpl.Address, ArbOwner, emitOwnerActs))
-	insert(debugOnly(MakePrecompile(templates.ArbDebugMetaData, &ArbDebug{Address: hex("ff")})))
+	insert(ownerOnly(ArbOwner.address, ArbOwner, emitOwnerActs))
+	insert(debugOnly(ArbDebug.address, ArbDebug))
+
+	insert(ArbosActs.address, ArbosActs)
+	if installHooks {
+		arbos.InternalTxStartBlockMethodID = ArbosActs.GetMethodID("StartBlock")
+		arbos.InternalTxBatchPostingReportMethodID = ArbosActs.GetMethodID("BatchPostingReport")
+	}
+
+	for _, opt := range opts {
+		opt(contracts)
+	}
+	return contracts, nil
+}
+
+// PrecompileNames maps each precompile's address to its contract name.
+// Like the other introspection helpers, it leaves ArbOS's hooks alone, so a running node may call it.
+func PrecompileNames() (map[addr]string, error) {
+	precompiles, err := makePrecompiles(false)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[addr]string, len(precompiles))
+	for address, precompile := range precompiles {
+		names[address] = precompile.Precompile().Name()
+	}
+	return names, nil
+}
+
+// AddressedPrecompile pairs a precompile with its address and contract name
+type AddressedPrecompile struct {
+	Addr addr
+	Name string
+	P    ArbosPrecompile
+}
+
+// PrecompilesSorted lists the precompiles in order of their addresses
+func PrecompilesSorted() ([]AddressedPrecompile, error) {
+	precompiles, err := makePrecompiles(false)
+	if err != nil {
+		return nil, err
+	}
+	sorted := make([]AddressedPrecompile, 0, len(precompiles))
+	for address, precompile := range precompiles {
+		sorted = append(sorted, AddressedPrecompile{address, precompile.Precompile().Name(), precompile})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].Addr[:], sorted[j].Addr[:]) < 0
+	})
+	return sorted, nil
+}
+
+// MethodDescription summarizes a precompile method for documentation and RPC schemas
+type MethodDescription struct {
+	Selector   bytes4
+	Signature  string
+	Mutability Mutability
+	Inputs     abi.Arguments
+	Outputs    abi.Arguments
+}
+
+// PrecompileDescription summarizes a precompile and its methods, which are sorted by name
+type PrecompileDescription struct {
+	Address addr
+	Name    string
+	Methods []MethodDescription
+}
+
+// DescribePrecompiles summarizes every precompile in order of their addresses
+func DescribePrecompiles() ([]PrecompileDescription, error) {
+	sorted, err := PrecompilesSorted()
+	if err != nil {
+		return nil, err
+	}
+	descriptions := make([]PrecompileDescription, 0, len(sorted))
+	for _, entry := range sorted {
+		precompile := entry.P.Precompile()
+		description := PrecompileDescription{Address: entry.Addr, Name: entry.Name}
+		for _, name := range precompile.Methods() {
+			method := precompile.methodsByName[name]
+			description.Methods = append(description.Methods, MethodDescription{
+				Selector:   *(*bytes4)(method.template.ID),
+				Signature:  method.template.Sig,
+				Mutability: method.purity,
+				Inputs:     method.template.Inputs,
+				Outputs:    method.template.Outputs,
+			})
+		}
+		descriptions = append(descriptions, description)
+	}
+	return descriptions, nil
+}
 
-	ArbosActs := insert(MakePrecompile(templates.ArbosActsMetaData, &ArbosActs{Address: types.ArbosAddress}))
-	arbos.InternalTxStartBlockMethodID = ArbosActs.GetMethodID("StartBlock")
-	arbos.InternalTxBatchPostingReportMethodID = ArbosActs.GetMethodID("BatchPostingReport")
+// selectorEntry is a row of ExportSelectorsJSON's output
+type selectorEntry struct {
+	Address    common.Address `json:"address"`
+	Contract   string         `json:"contract"`
+	Method     string         `json:"method"`
+	Selector   hexutil.Bytes  `json:"selector"`
+	Signature  string         `json:"signature"`
+	Mutability string         `json:"mutability"`
+}
 
-	return contracts
+// ExportSelectorsJSON lists every precompile method's selector as JSON, sorted by address and then selector
+func ExportSelectorsJSON() ([]byte, error) {
+	descriptions, err := DescribePrecompiles()
+	if err != nil {
+		return nil, err
+	}
+	entries := []selectorEntry{}
+	for _, description := range descriptions {
+		methods := append([]MethodDescription{}, description.Methods...)
+		sort.Slice(methods, func(i, j int) bool {
+			return bytes.Compare(methods[i].Selector[:], methods[j].Selector[:]) < 0
+		})
+		for _, method := range methods {
+			entries = append(entries, selectorEntry{
+				Address:    description.Address,
+				Contract:   description.Name,
+				Method:     strings.Split(method.Signature, "(")[0],
+				Selector:   common.CopyBytes(method.Selector[:]),
+				Signature:  method.Signature,
+				Mutability: method.Mutability.String(),
+			})
+		}
+	}
+	return json.MarshalIndent(entries, "", "  ")
 }
 
 func (p *Precompile) CloneWithImpl(impl interface{}) *Precompile {
@@ -616,6 +1406,232 @@ func (p *Precompile) GetMethodID(name string) bytes4 {
 	return *(*bytes4)(method.template.ID)
 }
 
+// Name returns the precompile's contract name
+func (p *Precompile) Name() string {
+	return p.name
+}
+
+// SetL1PricingOracle charges calls for the L1 data they imply, or stops doing so when nil.
+// Since this changes how much gas calls use, chains must only install an oracle at a coordinated upgrade.
+func (p *Precompile) SetL1PricingOracle(oracle L1PricingOracle) {
+	p.l1Pricer = oracle
+}
+
+// SetReentrancyGuard makes calls that reenter the precompile, such as from a contract one of its handlers
+// executes, revert. Precompiles that expect to be reentered should leave the guard disabled.
+func (p *Precompile) SetReentrancyGuard(enabled bool) {
+	p.reentrancyGuard = enabled
+}
+
+// SetGasFloor sets a base cost charged for every call to one of the precompile's methods, which is 0 by default
+func (p *Precompile) SetGasFloor(gas uint64) {
+	p.gasFloor = gas
+}
+
+// SetAllowDelegatecall lets the precompile be called when it isn't acting as itself,
+// as happens during a delegatecall or callcode. Note that the caller may be wrong in that case.
+func (p *Precompile) SetAllowDelegatecall(allow bool) {
+	p.allowDelegatecall = allow
+}
+
+// SetAllowActingAsStorage lets the view and write methods of a delegatecall-enabled precompile run while acting as
+// another account, so that their handlers may read and write that account's storage via the context.
+//
+// This weakens the guarantees impure methods otherwise have. The account being acted as controls its own storage,
+// so anything read from it is untrusted, and writes land in that account rather than the precompile. Handlers still
+// see the ArbOS state, and their caller may be wrong, so they must not use either to authorize what they do on the
+// acting account's behalf. Owner-only methods are never run this way, and the setting has no effect unless
+// SetAllowDelegatecall is also set.
+func (p *Precompile) SetAllowActingAsStorage(allow bool) {
+	p.actingAsStorage = allow
+}
+
+// ValidateImplementer checks that the implementer supports the bindings without making a precompile for it,
+// returning an error that describes every missing or mistyped method, event, and custom error.
+// The check is done on a fresh instance of the implementer's type, whose fields are left untouched.
+func ValidateImplementer(metadata *bind.MetaData, implementer interface{}) error {
+	implementerType := reflect.TypeOf(implementer)
+	if implementerType == nil || implementerType.Kind() != reflect.Pointer || implementerType.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("implementer must be a pointer to a struct but is %v", implementerType)
+	}
+	_, _, err := makePrecompile(metadata, reflect.New(implementerType.Elem()).Interface(), false)
+	return err
+}
+
+// AuditGasCosts lists, for review, the methods whose GasCost prices empty inputs at 0 gas or panics on them.
+// Such methods might be free to call. This is advisory, so each finding is only logged as a warning
+// when gethhook installs the precompiles.
+func AuditGasCosts(p *Precompile) []string {
+	suspicious := []string{}
+	for _, name := range p.Methods() {
+		method := p.methodsByName[name]
+		if method.constantGas != nil && *method.constantGas == 0 {
+			log.Warn("precompile method may be free to call", "precompile", p.name, "method", name, "panicked", false)
+			suspicious = append(suspicious, name)
+		}
+		if method.gasCost == nil {
+			continue
+		}
+		args := []reflect.Value{p.implementer}
+		for _, inputType := range method.inputTypes {
+			arg := reflect.Zero(inputType)
+			if inputType == reflect.TypeOf(&big.Int{}) {
+				arg = reflect.ValueOf(new(big.Int))
+			}
+			args = append(args, arg)
+		}
+		cost, panicked := func() (cost uint64, panicked bool) {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					panicked = true
+				}
+			}()
+			results := method.gasCost.Func.Call(args)
+			if err, _ := results[1].Interface().(error); err != nil {
+				return 1, false // rejecting the input isn't free
+			}
+			return results[0].Uint(), false
+		}()
+		if panicked || cost == 0 {
+			log.Warn("precompile method may be free to call", "precompile", p.name, "method", name, "panicked", panicked)
+			suspicious = append(suspicious, name)
+		}
+	}
+	return suspicious
+}
+
+// PackCall encodes a call to a precompile's method, given by its solidity name, for clients to send
+func PackCall(metadata *bind.MetaData, method string, args ...interface{}) ([]byte, error) {
+	source, err := metadata.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := source.Methods[method]; !ok {
+		return nil, fmt.Errorf("no method named %v", method)
+	}
+	return source.Pack(method, args...)
+}
+
+// SetMethodArbosVersions sets the ArbOS version at which each named method becomes available
+func (p *Precompile) SetMethodArbosVersions(versions map[string]uint64) error {
+	for name := range versions {
+		if _, ok := p.methodsByName[name]; !ok {
+			return fmt.Errorf("precompile %v does not have a method with the name %v", p.name, name)
+		}
+	}
+	for name, version := range versions {
+		p.methodsByName[name].arbosVersion = version
+	}
+	return nil
+}
+
+// SetEventArbosVersions sets the ArbOS version at which each named event begins being emitted.
+// Before then, emitting the event does nothing and costs no gas.
+func (p *Precompile) SetEventArbosVersions(versions map[string]uint64) error {
+	for name := range versions {
+		if _, ok := p.events[name]; !ok {
+			return fmt.Errorf("precompile %v does not have an event with the name %v", p.name, name)
+		}
+	}
+	for name, version := range versions {
+		*p.events[name].activeFrom = version
+	}
+	return nil
+}
+
+// SetMaxInputSize bounds the calldata the precompile will decode, with 0 meaning no limit
+func (p *Precompile) SetMaxInputSize(size uint64) {
+	p.maxInputSize = size
+}
+
+// SetOwnerOnly restricts the named methods to callers the check authorizes
+func (p *Precompile) SetOwnerOnly(check OwnerCheck, names ...string) error {
+	for _, name := range names {
+		if _, ok := p.methodsByName[name]; !ok {
+			return fmt.Errorf("precompile %v does not have a method with the name %v", p.name, name)
+		}
+	}
+	p.ownerCheck = check
+	for _, name := range names {
+		p.methodsByName[name].ownerOnly = true
+	}
+	return nil
+}
+
+// MethodByID resolves a 4-byte selector to the method's name and solidity ABI
+func (p *Precompile) MethodByID(id [4]byte) (string, abi.Method, bool) {
+	method, ok := p.methods[id]
+	if !ok {
+		return "", abi.Method{}, false
+	}
+	return method.name, method.template, true
+}
+
+// selectorFromInput reads the method selector leading the calldata, if there's room for one
+func selectorFromInput(input []byte) (bytes4, bool) {
+	if len(input) < 4 {
+		return bytes4{}, false
+	}
+	return *(*bytes4)(input), true
+}
+
+// DecodeInput names the method the calldata invokes and maps each of its argument names to the decoded value.
+// Unnamed arguments are keyed by their position, such as "arg0".
+func (p *Precompile) DecodeInput(input []byte) (string, map[string]interface{}, error) {
+	id, ok := selectorFromInput(input)
+	if !ok {
+		return "", nil, fmt.Errorf("calldata too short for a method selector: %v bytes", len(input))
+	}
+	method, ok := p.methods[id]
+	if !ok {
+		return "", nil, fmt.Errorf("precompile %v has no method with selector %x", p.name, input[:4])
+	}
+	values, err := method.template.Inputs.Unpack(input[4:])
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to decode the calldata of %v: %w", method.template.RawName, err)
+	}
+	args := make(map[string]interface{}, len(values))
+	for i, value := range values {
+		name := method.template.Inputs[i].Name
+		if name == "" {
+			name = fmt.Sprintf("arg%v", i)
+		}
+		args[name] = value
+	}
+	return method.name, args, nil
+}
+
+// Mutability returns the state mutability of the method with the given selector
+func (p *Precompile) Mutability(id [4]byte) (Mutability, bool) {
+	method, ok := p.methods[id]
+	if !ok {
+		return 0, false
+	}
+	return method.purity, true
+}
+
+// Methods returns the sorted names of the precompile's methods
+func (p *Precompile) Methods() []string {
+	names := make([]string, 0, len(p.methodsByName))
+	for name := range p.methodsByName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Events returns the precompile's events sorted by name, each with the ID logs carry as their first topic
+func (p *Precompile) Events() []abi.Event {
+	events := make([]abi.Event, 0, len(p.events))
+	for _, event := range p.events {
+		events = append(events, event.template)
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Name < events[j].Name
+	})
+	return events
+}
+
 // Call a precompile in typed form, deserializing its inputs and serializing its outputs
 func (p *Precompile) Call(
 	input []byte,
@@ -627,6 +1643,143 @@ func (p *Precompile) Call(
 	gasSupplied uint64,
 	evm *vm.EVM,
 ) (output []byte, gasLeft uint64, err error) {
+	return p.call(input, precompileAddress, actingAsAddress, caller, value, readOnly, gasSupplied, evm, nil)
+}
+
+// CallStatic simulates a read-only call to the precompile, returning the logs it would emit
+// instead of adding them to the state. Write and payable methods revert, as in a static call.
+func (p *Precompile) CallStatic(
+	input []byte,
+	precompileAddress common.Address,
+	caller common.Address,
+	gasSupplied uint64,
+	evm *vm.EVM,
+) (output []byte, gasLeft uint64, logs []*types.Log, err error) {
+	logs = []*types.Log{}
+	output, gasLeft, err = p.call(
+		input, precompileAddress, precompileAddress, caller, common.Big0, true, gasSupplied, evm, &logs,
+	)
+	return output, gasLeft, logs, err
+}
+
+// ErrUndecodableOutput is returned by CallTyped when a successful call's output can't be decoded
+var ErrUndecodableOutput = errors.New("precompile output could not be decoded")
+
+// CallTyped is like Call, but decodes the output into the method's go values. A revert is returned as the
+// error Call gives, annotated with its reason when there is one, while decode failures wrap ErrUndecodableOutput.
+func (p *Precompile) CallTyped(
+	input []byte,
+	precompileAddress common.Address,
+	actingAsAddress common.Address,
+	caller common.Address,
+	value *big.Int,
+	readOnly bool,
+	gasSupplied uint64,
+	evm *vm.EVM,
+) ([]interface{}, error) {
+	output, _, err := p.Call(input, precompileAddress, actingAsAddress, caller, value, readOnly, gasSupplied, evm)
+	if err != nil {
+		if reason, unpackErr := abi.UnpackRevert(output); unpackErr == nil {
+			return nil, fmt.Errorf("%w: %v", err, reason)
+		}
+		return nil, err
+	}
+	id, _ := selectorFromInput(input)
+	method, ok := p.methods[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: the call didn't match a method", ErrUndecodableOutput)
+	}
+	values, err := method.template.Outputs.Unpack(output)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUndecodableOutput, err)
+	}
+	return values, nil
+}
+
+// CallBuffered is like Call, except that the events emitted by pure and view methods are returned rather than
+// rejected. Write and payable methods persist their logs as usual, so for them the returned logs are always empty.
+// Logs emitted by a call that fails are discarded.
+func (p *Precompile) CallBuffered(
+	input []byte,
+	precompileAddress common.Address,
+	actingAsAddress common.Address,
+	caller common.Address,
+	value *big.Int,
+	readOnly bool,
+	gasSupplied uint64,
+	evm *vm.EVM,
+) (output []byte, gasLeft uint64, logs []*types.Log, err error) {
+	var buffer *[]*types.Log
+	if id, ok := selectorFromInput(input); ok {
+		method, ok := p.methods[id]
+		if ok && method.purity <= view && arbosState.ArbOSVersion(evm.StateDB) >= method.arbosVersion {
+			logs = []*types.Log{}
+			buffer = &logs
+		}
+	}
+	output, gasLeft, err = p.call(
+		input, precompileAddress, actingAsAddress, caller, value, readOnly, gasSupplied, evm, buffer,
+	)
+	if err != nil {
+		return output, gasLeft, nil, err
+	}
+	return output, gasLeft, logs, nil
+}
+
+// Simulate dry-runs a call to the precompile from the tx origin, reporting the gas it would use under the cap.
+// Any state changes the call makes are reverted, though callers should still provide a disposable EVM.
+func (p *Precompile) Simulate(input []byte, evm *vm.EVM, gasCap uint64) (output []byte, gasUsed uint64, err error) {
+	snapshot := evm.StateDB.Snapshot()
+	defer evm.StateDB.RevertToSnapshot(snapshot)
+
+	output, gasLeft, err := p.call(input, p.address, p.address, evm.TxContext.Origin, common.Big0, false, gasCap, evm, nil)
+	return output, gasCap - gasLeft, err
+}
+
+func (p *Precompile) call(
+	input []byte,
+	precompileAddress common.Address,
+	actingAsAddress common.Address,
+	caller common.Address,
+	value *big.Int,
+	readOnly bool,
+	gasSupplied uint64,
+	evm *vm.EVM,
+	logs *[]*types.Log,
+) (output []byte, gasLeft uint64, err error) {
+	methodName := ""
+	if tracer, ok := evm.Config.Tracer.(PrecompileTracer); ok {
+		defer func() {
+			tracer.CapturePrecompile(precompileAddress, methodName, input, output, gasSupplied-gasLeft, err)
+		}()
+	}
+	if sink := metricsSink.Load(); sink != nil {
+		defer func() {
+			(*sink).RecordCall(p.name, methodName, gasSupplied-gasLeft, err != nil)
+		}()
+	}
+	var handlerErr error // the handler's own error, which the returned error may not convey
+	if logger := revertLogger.Load(); logger != nil {
+		defer func() {
+			if err == nil {
+				return
+			}
+			reason := handlerErr
+			if reason == nil {
+				reason = err
+				if message, unpackErr := abi.UnpackRevert(output); unpackErr == nil {
+					reason = fmt.Errorf("%w: %v", err, message)
+				}
+			}
+			(*logger).LogRevert(p.name, methodName, caller, reason)
+		}()
+	}
+
+	if value == nil {
+		// some callers represent a zero callvalue as nil
+		value = new(big.Int)
+	}
+
 	arbosVersion := arbosState.ArbOSVersion(evm.StateDB)
 
 	if arbosVersion < p.arbosVersion {
@@ -634,47 +1787,101 @@ func (p *Precompile) Call(
 		return []byte{}, gasSupplied, nil
 	}
 
-	if len(input) < 4 {
-		// ArbOS precompiles always have canonical method selectors
-		return nil, 0, vm.ErrExecutionReverted
+	if p.maxInputSize != 0 && uint64(len(input)) > p.maxInputSize {
+		// refuse to decode oversized calldata
+		return revertReason("ArbOS: input too large"), 0, vm.ErrExecutionReverted
 	}
-	id := *(*[4]byte)(input)
-	method, ok := p.methods[id]
-	if !ok || arbosVersion < method.arbosVersion {
-		// method does not exist or hasn't yet been activated
-		return nil, 0, vm.ErrExecutionReverted
+
+	var method *PrecompileMethod
+	if id, ok := selectorFromInput(input); ok {
+		method = p.methods[id]
 	}
+	if method != nil && arbosVersion < method.arbosVersion && p.fallback == nil {
+		// the method exists but hasn't yet been activated
+		return versionedRevertReason(arbosVersion, "ArbOS: method not available at this ArbOS version"), 0, vm.ErrExecutionReverted
+	}
+	if method == nil || arbosVersion < method.arbosVersion {
+		switch {
+		case p.fallback != nil:
+			method = p.fallback
+		case len(input) < 4:
+			// ArbOS precompiles always have canonical method selectors
+			return versionedRevertReason(arbosVersion, "ArbOS: calldata too short for a method selector"), 0, vm.ErrExecutionReverted
+		default:
+			// method does not exist or hasn't yet been activated
+			return versionedRevertReason(arbosVersion, "ArbOS: method not found"), 0, vm.ErrExecutionReverted
+		}
+	}
+	methodName = method.name
 
-	if method.purity >= view && actingAsAddress != precompileAddress {
+	guardsDelegatecalls := arbosVersion >= arbostypes.ArbosVersion_PrecompileDelegatecallGuard
+	if actingAsAddress != precompileAddress && !p.allowDelegatecall && guardsDelegatecalls {
+		// this is a delegatecall or callcode, so the caller might be wrong.
+		// Older ArbOS versions let pure methods through, so replaying them must too.
+		return nil, 0, ErrDelegatecall
+	}
+
+	actsForOthers := p.allowDelegatecall && p.actingAsStorage && !method.ownerOnly
+	if method.purity >= view && actingAsAddress != precompileAddress && !actsForOthers {
 		// should not access precompile superpowers when not acting as the precompile
-		return nil, 0, vm.ErrExecutionReverted
+		return versionedRevertReason(arbosVersion, "precompile: not acting as self"), 0, ErrNotActingAsSelf
 	}
 
 	if method.purity >= write && readOnly {
 		// tried to write to global state in read-only mode
-		return nil, 0, vm.ErrExecutionReverted
+		return versionedRevertReason(arbosVersion, "precompile: read-only"), 0, ErrReadOnlyCall
 	}
 
 	if method.purity < payable && value.Sign() != 0 {
 		// tried to pay something that's non-payable
-		return nil, 0, vm.ErrExecutionReverted
+		return versionedRevertReason(arbosVersion, "precompile: non-payable"), 0, ErrNonPayableValue
 	}
 
 	callerCtx := &Context{
 		caller:      caller,
+		origin:      evm.TxContext.Origin,
+		depth:       evm.Depth(),
+		callvalue:   value,
 		gasSupplied: gasSupplied,
 		gasLeft:     gasSupplied,
 		readOnly:    method.purity <= view,
+		actingAs:    actingAsAddress,
+		canRead:     method.purity >= view,
 		tracingInfo: util.NewTracingInfo(evm, caller, precompileAddress, util.TracingDuringEVM),
+		stateDB:     evm.StateDB,
+		logs:        logs,
 	}
 
-	argsCost := params.CopyGas * arbmath.WordsForBytes(uint64(len(input)-4))
+	calldata := input
+	if !method.fallback {
+		calldata = input[4:]
+	}
+	argsCost := copyCost(calldata)
 	if err := callerCtx.Burn(argsCost); err != nil {
 		// user cannot afford the argument data supplied
 		return nil, 0, vm.ErrExecutionReverted
 	}
 
-	if method.purity != pure {
+	if !method.fallback {
+		if err := callerCtx.Burn(p.gasFloor); err != nil {
+			// user cannot afford the base cost of calling a method
+			return nil, 0, vm.ErrExecutionReverted
+		}
+	}
+
+	if p.l1Pricer != nil {
+		l1Cost, err := p.l1Pricer.L1GasCost(method.name, calldata)
+		if err != nil {
+			log.Error("failed to price a precompile call's L1 data", "precompile", precompileAddress, "method", method.name, "err", err)
+			return nil, callerCtx.gasLeft, vm.ErrExecutionReverted
+		}
+		if err := callerCtx.Burn(l1Cost); err != nil {
+			// user cannot afford the L1 data the call implies
+			return nil, 0, vm.ErrExecutionReverted
+		}
+	}
+
+	if method.purity != pure || method.ownerOnly {
 		// impure methods may need the ArbOS state, so open & update the call context now
 		state, err := arbosState.OpenArbosState(evm.StateDB, callerCtx)
 		if err != nil {
@@ -694,35 +1901,83 @@ func (p *Precompile) Call(
 		return nil, 0, vm.ErrExecutionReverted
 	}
 
-	reflectArgs := []reflect.Value{
-		p.implementer,
-		reflect.ValueOf(callerCtx),
+	if p.reentrancyGuard {
+		// the tx processor tracks the call stack, and every exit path below must release the guard, including reverts
+		defer callerCtx.txProcessor.ExitPrecompile(precompileAddress)
+		if !callerCtx.txProcessor.EnterPrecompile(precompileAddress) {
+			return revertReason("reentrant call"), 0, vm.ErrExecutionReverted
+		}
 	}
 
-	switch method.purity {
-	case pure:
-	case view:
-		reflectArgs = append(reflectArgs, reflect.ValueOf(evm))
-	case write:
-		reflectArgs = append(reflectArgs, reflect.ValueOf(evm))
-	case payable:
-		reflectArgs = append(reflectArgs, reflect.ValueOf(evm))
-		reflectArgs = append(reflectArgs, reflect.ValueOf(value))
-	default:
-		log.Crit("Unknown state mutability ", method.purity)
+	if method.ownerOnly {
+		authorized, err := p.ownerCheck(callerCtx, evm)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !authorized {
+			reason := fmt.Sprintf("ArbOS: caller is not authorized to call %v", method.template.RawName)
+			return revertReason(reason), callerCtx.gasLeft, vm.ErrExecutionReverted
+		}
 	}
 
-	args, err := method.template.Inputs.Unpack(input[4:])
-	if err != nil {
-		// calldata does not match the method's signature
-		return nil, 0, vm.ErrExecutionReverted
+	reflectArgs := method.handlerArgs(p.implementer, callerCtx, evm, value)
+
+	// zero-argument methods skip decoding entirely, ignoring any trailing calldata just as geth's Unpack would
+	if method.fallback {
+		reflectArgs = append(reflectArgs, reflect.ValueOf(calldata))
+	} else if len(method.inputTypes) > 0 {
+		args, err := method.template.Inputs.Unpack(calldata)
+		if err != nil {
+			// calldata does not match the method's signature
+			reason := fmt.Sprintf("ArbOS: calldata decode failed for method %v", method.template.RawName)
+			return versionedRevertReason(arbosVersion, reason), 0, vm.ErrExecutionReverted
+		}
+		for i, arg := range args {
+			if variants, ok := method.enumVariants[i]; ok && uint64(arg.(uint8)) >= variants { //nolint:errcheck
+				return revertReason("invalid enum value"), callerCtx.gasLeft, vm.ErrExecutionReverted
+			}
+			converted := reflect.ValueOf(arg).Convert(method.inputTypes[i])
+			reflectArgs = append(reflectArgs, converted)
+		}
 	}
-	for _, arg := range args {
-		converted := reflect.ValueOf(arg).Convert(method.handler.Type.In(len(reflectArgs)))
-		reflectArgs = append(reflectArgs, converted)
+
+	if method.constantGas != nil {
+		if err := callerCtx.Burn(*method.constantGas); err != nil {
+			// user cannot afford the method's cost
+			return nil, 0, vm.ErrExecutionReverted
+		}
+	} else if method.gasCost != nil {
+		pricerArgs := append([]reflect.Value{p.implementer}, reflectArgs[method.contextArgs:]...)
+		priced, err := callHandler(*method.gasCost, pricerArgs)
+		if err != nil {
+			log.Error("precompile pricer panicked", "precompile", precompileAddress, "input", input, "err", err)
+			handlerErr = err
+			if arbosVersion < arbostypes.ArbosVersion_PrecompileRevertReasons {
+				// older versions treat internal failures as consuming all gas
+				return nil, 0, vm.ErrExecutionReverted
+			}
+			return revertReason("ArbOS: internal precompile error"), callerCtx.gasLeft, vm.ErrExecutionReverted
+		}
+		if err, _ := priced[1].Interface().(error); err != nil {
+			// the method refuses these inputs
+			return revertReason(err.Error()), callerCtx.gasLeft, vm.ErrExecutionReverted
+		}
+		if err := callerCtx.Burn(priced[0].Uint()); err != nil {
+			// user cannot afford the method's input-dependent cost
+			return nil, 0, vm.ErrExecutionReverted
+		}
 	}
 
-	reflectResult := method.handler.Func.Call(reflectArgs)
+	reflectResult, err := callHandler(method.handler, reflectArgs)
+	if err != nil {
+		log.Error("precompile handler panicked", "precompile", precompileAddress, "input", input, "err", err)
+		handlerErr = err
+		if arbosVersion < arbostypes.ArbosVersion_PrecompileRevertReasons {
+			// older versions treat internal failures as consuming all gas
+			return nil, 0, vm.ErrExecutionReverted
+		}
+		return revertReason("ArbOS: internal precompile error"), callerCtx.gasLeft, vm.ErrExecutionReverted
+	}
 	resultCount := len(reflectResult) - 1
 	if !reflectResult[resultCount].IsNil() {
 		// the last arg is always the error status
@@ -731,15 +1986,29 @@ func (p *Precompile) Call(
 			log.Error("final precompile return value must be error")
 			return nil, callerCtx.gasLeft, vm.ErrExecutionReverted
 		}
-		var solErr *SolError
-		isSolErr := errors.As(errRet, &solErr)
-		if isSolErr {
-			resultCost := params.CopyGas * arbmath.WordsForBytes(uint64(len(solErr.data)))
+		handlerErr = errRet
+		var encodingErr encodingError
+		if errors.As(errRet, &encodingErr) && arbosVersion < arbostypes.ArbosVersion_PrecompileRevertReasons {
+			if arbosVersion < 11 {
+				// the oldest versions zero out gas, failing with the packing error itself
+				return nil, 0, encodingErr.cause
+			}
+			// older versions revert without explaining the encoding failure
+			return nil, callerCtx.gasLeft, vm.ErrExecutionReverted
+		}
+		var solErr SolidityError
+		if errors.As(errRet, &solErr) {
+			data, err := solErr.Encode()
+			if err != nil {
+				log.Error("could not encode precompile's solidity error", "err", err)
+				return nil, callerCtx.gasLeft, vm.ErrExecutionReverted
+			}
+			resultCost := copyCost(data)
 			if err := callerCtx.Burn(resultCost); err != nil {
 				// user cannot afford the result data returned
 				return nil, 0, vm.ErrExecutionReverted
 			}
-			return solErr.data, callerCtx.gasLeft, vm.ErrExecutionReverted
+			return data, callerCtx.gasLeft, vm.ErrExecutionReverted
 		}
 		if !errors.Is(errRet, vm.ErrOutOfGas) {
 			log.Debug("precompile reverted with non-solidity error", "precompile", precompileAddress, "input", input, "err", errRet)
@@ -751,26 +2020,87 @@ func (p *Precompile) Call(
 		// Preserve behavior with old versions which would zero out gas on this type of error
 		return nil, 0, errRet
 	}
-	result := make([]interface{}, resultCount)
-	for i := 0; i < resultCount; i++ {
-		result[i] = reflectResult[i].Interface()
-	}
+	var encoded []byte
+	if method.rawOutput {
+		// the handler may return a slice of the calldata, which the caller's memory backs
+		encoded = common.CopyBytes(reflectResult[0].Bytes())
+	} else if len(method.template.Outputs) == 0 {
+		// void methods return no data
+		encoded = []byte{}
+	} else {
+		var result []interface{}
+		if method.structOutput {
+			fields := reflectResult[0]
+			result = make([]interface{}, fields.NumField())
+			for i := range result {
+				result[i] = normalizeOutput(method.template.Outputs[i], fields.Field(i))
+			}
+		} else {
+			result = make([]interface{}, resultCount)
+			for i := 0; i < resultCount; i++ {
+				result[i] = normalizeOutput(method.template.Outputs[i], reflectResult[i])
+			}
+		}
 
-	encoded, err := method.template.Outputs.PackValues(result)
-	if err != nil {
-		log.Error("could not encode precompile result", "err", err)
-		return nil, callerCtx.gasLeft, vm.ErrExecutionReverted
+		if arbosVersion >= arbostypes.ArbosVersion_PrecompileRevertReasons {
+			// older versions encode oversized outputs as whole words, as they always have
+			for i, output := range method.template.Outputs {
+				if err := checkOutputWidth(output.Type, result[i]); err != nil {
+					log.Error("precompile result overflows its type", "precompile", precompileAddress, "method", method.name, "err", err)
+					return revertReason(err.Error()), callerCtx.gasLeft, vm.ErrExecutionReverted
+				}
+			}
+		}
+
+		encoded, err = method.template.Outputs.PackValues(result)
+		if err != nil {
+			log.Error("could not encode precompile result", "precompile", precompileAddress, "method", method.name, "err", err)
+			reason := newEncodingError("the outputs of "+method.template.RawName, err).reason
+			return versionedRevertReason(arbosVersion, reason), callerCtx.gasLeft, vm.ErrExecutionReverted
+		}
 	}
 
-	resultCost := params.CopyGas * arbmath.WordsForBytes(uint64(len(encoded)))
+	resultCost := copyCost(encoded)
 	if err := callerCtx.Burn(resultCost); err != nil {
 		// user cannot afford the result data returned
 		return nil, 0, vm.ErrExecutionReverted
 	}
 
+	if refund := callerCtx.valueRefund; refund != nil && refund.Sign() > 0 {
+		// return the unused callvalue, which was deposited to this precompile's account
+		err := util.TransferBalance(&precompileAddress, &caller, refund, evm, util.TracingDuringEVM, "refund")
+		if err != nil {
+			log.Error("failed to refund precompile callvalue", "err", err)
+			return nil, callerCtx.gasLeft, vm.ErrExecutionReverted
+		}
+	}
+
 	return encoded, callerCtx.gasLeft, nil
 }
 
+// handlerArgs lays out the handler's leading args, which are the contract, the context if taken, and depending
+// on purity the evm & callvalue, leaving room for the inputs
+func (method *PrecompileMethod) handlerArgs(implementer reflect.Value, callerCtx *Context, evm *vm.EVM, value *big.Int) []reflect.Value {
+	reflectArgs := make([]reflect.Value, method.contextArgs, method.contextArgs+len(method.inputTypes)+1)
+	reflectArgs[0] = implementer
+	if method.contextArgs > 1 {
+		reflectArgs[1] = reflect.ValueOf(callerCtx)
+	}
+	if method.contextArgs > 2 {
+		if method.usesEnv {
+			reflectArgs[2] = reflect.ValueOf(NewEnvironment(evm))
+		} else if method.usesChain {
+			reflectArgs[2] = reflect.ValueOf(NewChainContext(evm))
+		} else {
+			reflectArgs[2] = reflect.ValueOf(evm)
+		}
+	}
+	if method.contextArgs > 3 {
+		reflectArgs[3] = reflect.ValueOf(value)
+	}
+	return reflectArgs
+}
+
 func (p *Precompile) Precompile() *Precompile {
 	return p
 }
@@ -5,14 +5,27 @@ package precompiles
 
 import (
 	"bytes"
+	"errors"
+	"math"
 	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
 
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/offchainlabs/nitro/arbos"
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
 	"github.com/offchainlabs/nitro/arbos/storage"
 	templates "github.com/offchainlabs/nitro/solgen/go/precompilesgen"
 	"github.com/offchainlabs/nitro/util/arbmath"
@@ -25,7 +38,9 @@ func TestEvents(t *testing.T) {
 	evm.Context.BlockNumber = big.NewInt(int64(blockNumber))
 
 	debugContractAddr := common.HexToAddress("ff")
-	contract := Precompiles()[debugContractAddr]
+	precompiles, err := Precompiles()
+	Require(t, err)
+	contract := precompiles[debugContractAddr]
 
 	var method *PrecompileMethod
 	for _, available := range contract.Precompile().methods {
@@ -130,7 +145,9 @@ func TestEvents(t *testing.T) {
 
 func TestEventCosts(t *testing.T) {
 	debugContractAddr := common.HexToAddress("ff")
-	contract := Precompiles()[debugContractAddr]
+	precompiles, err := Precompiles()
+	Require(t, err)
+	contract := precompiles[debugContractAddr]
 
 	//nolint:errcheck
 	impl := contract.Precompile().implementer.Interface().(*ArbDebug)
@@ -179,6 +196,2519 @@ func TestEventCosts(t *testing.T) {
 	}
 }
 
+type missingAddressImpl struct{}
+
+// ABIs shared by the tests of implementers that satisfy them and those that don't
+const (
+	enumABI = `[
+		{"type":"function","name":"setMode","stateMutability":"nonpayable","inputs":[{"name":"mode","type":"uint8"}],"outputs":[{"name":"","type":"uint8"}]}
+	]`
+	widthsABI = `[
+		{"type":"function","name":"echo","stateMutability":"view","inputs":[
+			{"name":"small","type":"uint8"},{"name":"word","type":"uint64"},{"name":"wide","type":"uint256"}
+		],"outputs":[
+			{"name":"","type":"uint8"},{"name":"","type":"uint64"},{"name":"","type":"uint256"}
+		]}
+	]`
+	fixedArrayABI = `[
+		{"type":"function","name":"scale","stateMutability":"pure","inputs":[{"name":"key","type":"uint256[4]"}],"outputs":[{"name":"","type":"uint256[4]"}]}
+	]`
+	forwardABI = `[
+		{"type":"function","name":"forward","stateMutability":"view","inputs":[{"name":"callback","type":"function"}],"outputs":[{"name":"","type":"function"}]}
+	]`
+	overflowABI = `[
+		{"type":"function","name":"narrow","stateMutability":"view","inputs":[{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"uint128"}]},
+		{"type":"function","name":"signed","stateMutability":"view","inputs":[{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"int128"}]}
+	]`
+	touchABI = `[{"type":"function","name":"touch","stateMutability":"nonpayable","inputs":[],"outputs":[]}]`
+)
+
+func TestRejectedImplementers(t *testing.T) {
+	cases := []struct {
+		abi         string
+		implementer interface{}
+		problem     string // part of the error, when a particular one is expected
+	}{
+		{"[]", &missingAddressImpl{}, ""},
+		{templates.ArbInfoMetaData.ABI, &ArbosTest{}, ""}, // missing its methods
+		{`[
+			{"type":"function","name":"owner","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address"}]}
+		]`, &StringAddressTester{}, ""},
+		{touchABI, &VoidWithOutputTester{}, ""},     // void handlers must return only an error
+		{widthsABI, &NarrowingTester{}, ""},         // a handler mustn't silently truncate a wider input
+		{enumABI, &MisnamedEnumTester{}, ""},        // the enum tag names an unknown input
+		{fixedArrayABI, &SliceForArrayTester{}, ""}, // a slice isn't a fixed array, so it's caught at registration
+		{forwardABI, &FunctionSliceTester{}, "24-byte array"},
+		// a slice converts to an array in go, but its length can't be checked until it's too late
+		{`[
+			{"type":"function","name":"digest","stateMutability":"pure","inputs":[],"outputs":[{"name":"","type":"bytes32"}]}
+		]`, &SliceForFixedBytesTester{}, ""},
+	}
+	for _, test := range cases {
+		err := ValidateImplementer(&bind.MetaData{ABI: test.abi}, test.implementer)
+		if err == nil || !strings.Contains(err.Error(), test.problem) {
+			Fail(t, "expected", reflect.TypeOf(test.implementer).Elem().Name(), "to be rejected but got", err)
+		}
+	}
+}
+
+type BrokenImplementer struct {
+	Address addr
+	Named   func(ctx, mech, string) error
+}
+
+func (con BrokenImplementer) Declared(c ctx, evm mech, wrong bool) error {
+	return nil
+}
+
+func (con BrokenImplementer) Undeclared(c ctx, evm mech) error {
+	return nil
+}
+
+func TestValidateImplementer(t *testing.T) {
+	metadata := &bind.MetaData{ABI: `[
+		{"type":"function","name":"declared","stateMutability":"view",
+		 "inputs":[{"name":"value","type":"uint256"}],"outputs":[]},
+		{"type":"function","name":"absent","stateMutability":"view","inputs":[],"outputs":[]},
+		{"type":"event","name":"Named","anonymous":false,"inputs":[{"name":"name","type":"string","indexed":false}]}
+	]`}
+	impl := &BrokenImplementer{}
+	err := ValidateImplementer(metadata, impl)
+	if err == nil {
+		Fail(t, "expected the broken implementer to be rejected")
+	}
+	for _, problem := range []string{
+		"Declared's implementer has the wrong type",
+		"must implement Absent",
+		"missing a solidity interface for Undeclared",
+		"event Named's GasCost",
+	} {
+		if !strings.Contains(err.Error(), problem) {
+			Fail(t, "validation didn't report", problem, "in", err)
+		}
+	}
+	if impl.Named != nil {
+		Fail(t, "validation modified the implementer")
+	}
+
+	Require(t, ValidateImplementer(templates.ArbInfoMetaData, &ArbInfo{}))
+}
+
+func TestRevertReasons(t *testing.T) {
+	evm := newMockEVMForTestingAtArbosVersion(t, arbostypes.ArbosVersion_PrecompileRevertReasons)
+	precompiles, err := Precompiles()
+	Require(t, err)
+	infoAddr := common.HexToAddress("65")
+	info := precompiles[infoAddr]
+	getBalance := info.Precompile().GetMethodID("GetBalance")
+
+	call := func(input []byte) []byte {
+		t.Helper()
+		output, _, err := info.Call(input, infoAddr, infoAddr, common.Address{}, big.NewInt(0), false, 1000000, evm)
+		if !errors.Is(err, vm.ErrExecutionReverted) {
+			Fail(t, "expected a revert but got", err)
+		}
+		return output
+	}
+
+	cases := []struct {
+		input    []byte
+		expected string
+	}{
+		{[]byte{0x01, 0x02}, "ArbOS: calldata too short for a method selector"},
+		{[]byte{0x01, 0x02, 0x03}, "ArbOS: calldata too short for a method selector"},
+		{[]byte{0xde, 0xad, 0xbe, 0xef}, "ArbOS: method not found"},
+		{getBalance[:], "ArbOS: calldata decode failed for method getBalance"},
+	}
+	for _, test := range cases {
+		reason, err := abi.UnpackRevert(call(test.input))
+		Require(t, err, "failed to decode revert reason")
+		if reason != test.expected {
+			Fail(t, "unexpected revert reason", reason, "instead of", test.expected)
+		}
+	}
+
+	// older ArbOS versions revert without data, as they always have
+	evm = newMockEVMForTestingAtArbosVersion(t, arbostypes.ArbosVersion_PrecompileRevertReasons-1)
+	for _, test := range cases {
+		if output := call(test.input); len(output) != 0 {
+			Fail(t, "an old ArbOS version reverted with data", output)
+		}
+	}
+}
+
+type insufficientBalance struct {
+	have huge
+	want huge
+}
+
+func (e insufficientBalance) Error() string {
+	return "insufficient balance"
+}
+
+func (e insufficientBalance) Encode() ([]byte, error) {
+	selector := crypto.Keccak256([]byte("InsufficientBalance(uint256,uint256)"))[:4]
+	return append(selector, append(common.BigToHash(e.have).Bytes(), common.BigToHash(e.want).Bytes()...)...), nil
+}
+
+type SolidityErrorTester struct {
+	Address addr
+}
+
+func (con SolidityErrorTester) Withdraw(c ctx, evm mech, amount huge) error {
+	return insufficientBalance{big.NewInt(1), amount}
+}
+
+func TestHandlerReverts(t *testing.T) {
+	evm := newMockEVMForTestingAtArbosVersion(t, arbostypes.ArbosVersion_PrecompileRevertReasons)
+	oldEVM := newMockEVMForTestingAtArbosVersion(t, arbostypes.ArbosVersion_PrecompileRevertReasons-1)
+	address := common.HexToAddress("0x0101")
+	brokenABI := `[
+		{"type":"function","name":"broken","stateMutability":"view","inputs":[],"outputs":[]}
+	]`
+	insufficient, err := insufficientBalance{big.NewInt(1), big.NewInt(2)}.Encode()
+	Require(t, err)
+	uint128Limit := new(big.Int).Lsh(common.Big1, 128)
+	int128Limit := new(big.Int).Add(new(big.Int).Lsh(common.Big1, 127), common.Big1) // negated, it's 1 below int128's minimum
+	wrapped := new(big.Int).Sub(new(big.Int).Lsh(common.Big1, 256), int128Limit)     // its negation in two's complement
+
+	cases := []struct {
+		abi         string
+		implementer interface{}
+		method      string
+		args        []interface{}
+		expected    []byte // the revert data
+		old         []byte // what older ArbOS versions return instead
+		oldSucceeds bool   // whether older versions return it as a result rather than reverting
+	}{
+		{`[
+			{"type":"function","name":"withdraw","stateMutability":"nonpayable",
+			 "inputs":[{"name":"amount","type":"uint256"}],"outputs":[]}
+		]`, &SolidityErrorTester{Address: address}, "Withdraw", []interface{}{big.NewInt(2)}, insufficient, insufficient, false},
+		{brokenABI, &EncodingFailureTester{Address: address}, "Broken", nil,
+			revertReason("ArbOS: failed to encode event Broken: bad value"), nil, false},
+		{enumABI, &EnumTester{Address: address}, "SetMode", []interface{}{uint8(3)},
+			revertReason("invalid enum value"), revertReason("invalid enum value"), false},
+		// older versions encode oversized outputs as whole words
+		{overflowABI, &OverflowTester{Address: address}, "Narrow", []interface{}{uint128Limit},
+			revertReason("output overflows uint128"), common.BigToHash(uint128Limit).Bytes(), true},
+		{overflowABI, &OverflowTester{Address: address}, "Signed", []interface{}{int128Limit},
+			revertReason("output overflows int128"), common.BigToHash(wrapped).Bytes(), true},
+	}
+	for _, test := range cases {
+		precompile := makeTestPrecompile(t, test.abi, test.implementer)
+		input := packTestCall(t, precompile, test.method, test.args...)
+		output, _, err := callTestPrecompile(precompile, input, evm)
+		if !errors.Is(err, vm.ErrExecutionReverted) || !bytes.Equal(output, test.expected) {
+			Fail(t, test.method, "should revert with", test.expected, "but got", output, err)
+		}
+
+		output, _, err = callTestPrecompile(precompile, input, oldEVM)
+		if test.oldSucceeds != (err == nil) || (err != nil && !errors.Is(err, vm.ErrExecutionReverted)) {
+			Fail(t, test.method, "failed with", err, "on an old ArbOS version")
+		}
+		if !bytes.Equal(output, test.old) {
+			Fail(t, test.method, "returned", output, "instead of", test.old, "on an old ArbOS version")
+		}
+	}
+
+	// before ArbOS 11, encoding failures consumed all gas, failing with the packing error rather than reverting
+	precompile := makeTestPrecompile(t, brokenABI, &EncodingFailureTester{Address: address})
+	ancientEVM := newMockEVMForTestingAtArbosVersion(t, 10)
+	output, gasLeft, err := callTestPrecompile(precompile, packTestCall(t, precompile, "Broken"), ancientEVM)
+	if errors.Is(err, vm.ErrExecutionReverted) || err == nil || err.Error() != "bad value" || gasLeft != 0 || len(output) != 0 {
+		Fail(t, "unexpected encoding failure before ArbOS 11", output, gasLeft, err)
+	}
+}
+
+func TestMethodByID(t *testing.T) {
+	precompiles, err := Precompiles()
+	Require(t, err)
+	arbSys := precompiles[types.ArbSysAddress].Precompile()
+
+	name, method, ok := arbSys.MethodByID(arbSys.GetMethodID("ArbBlockNumber"))
+	if !ok || name != "ArbBlockNumber" || method.Sig != "arbBlockNumber()" {
+		Fail(t, "failed to resolve arbBlockNumber", name, method.Sig, ok)
+	}
+	if _, _, ok := arbSys.MethodByID([4]byte{0xde, 0xad, 0xbe, 0xef}); ok {
+		Fail(t, "resolved a selector that doesn't exist")
+	}
+
+	names := arbSys.Methods()
+	if len(names) != len(arbSys.methods) || !sort.StringsAreSorted(names) {
+		Fail(t, "unexpected method names", names)
+	}
+}
+
+type IndexedStringTester struct {
+	Address      addr
+	Named        func(ctx, mech, string, []byte, huge) error
+	NamedGasCost func(string, []byte, huge) (uint64, error)
+}
+
+const namedEventABI = `[
+	{"type":"event","name":"Named","anonymous":false,"inputs":[
+		{"name":"name","type":"string","indexed":true},
+		{"name":"blob","type":"bytes","indexed":true},
+		{"name":"value","type":"uint256","indexed":false}
+	]}
+]`
+
+func TestIndexedTopics(t *testing.T) {
+	named := &IndexedStringTester{Address: common.HexToAddress("0x0102")}
+	toggled := &BoolTopicTester{Address: common.HexToAddress("0x012f")}
+	listed := &IndexedArrayTester{Address: common.HexToAddress("0x011b")}
+	makeTestPrecompile(t, namedEventABI, named)
+	makeTestPrecompile(t, `[
+		{"type":"event","name":"Toggled","anonymous":false,"inputs":[
+			{"name":"on","type":"bool","indexed":true}
+		]}
+	]`, toggled)
+	makeTestPrecompile(t, `[
+		{"type":"event","name":"Listed","anonymous":false,"inputs":[
+			{"name":"accounts","type":"address[]","indexed":true},
+			{"name":"value","type":"uint256","indexed":false}
+		]}
+	]`, listed)
+
+	accounts := []addr{common.HexToAddress("0x01"), common.HexToAddress("0x02"), common.HexToAddress("0x03")}
+	var encoded []byte
+	for _, account := range accounts {
+		encoded = append(encoded, common.LeftPadBytes(account.Bytes(), 32)...)
+	}
+	var truth common.Hash
+	truth[31] = 0x01
+
+	cases := []struct {
+		emit   func(c ctx, evm mech) error
+		topics []common.Hash // those after the event's signature
+	}{
+		{func(c ctx, evm mech) error {
+			return named.Named(c, evm, "hello", []byte{0x01, 0x02}, big.NewInt(1))
+		}, []common.Hash{crypto.Keccak256Hash([]byte("hello")), crypto.Keccak256Hash([]byte{0x01, 0x02})}},
+		{func(c ctx, evm mech) error { return toggled.Toggled(c, evm, true) }, []common.Hash{truth}},
+		{func(c ctx, evm mech) error { return toggled.Toggled(c, evm, false) }, []common.Hash{{}}},
+		{func(c ctx, evm mech) error {
+			return listed.Listed(c, evm, accounts, big.NewInt(1))
+		}, []common.Hash{crypto.Keccak256Hash(encoded)}},
+	}
+	for _, test := range cases {
+		evm := newMockEVMForTesting()
+		Require(t, test.emit(testContext(common.Address{}, evm), evm))
+
+		//nolint:errcheck
+		logs := evm.StateDB.(*state.StateDB).Logs()
+		if len(logs) != 1 || !reflect.DeepEqual(logs[0].Topics[1:], test.topics) {
+			Fail(t, "expected topics", test.topics, "but got", logs)
+		}
+	}
+}
+
+type StaticCallTester struct {
+	Address       addr
+	Pinged        func(ctx, mech, huge) error
+	PingedGasCost func(huge) (uint64, error)
+}
+
+func (con StaticCallTester) Ping(c ctx, evm mech) error {
+	return con.Pinged(c, evm, big.NewInt(7))
+}
+
+func (con StaticCallTester) Register(c ctx, evm mech) error {
+	_, err := c.State.AddressTable().Register(con.Address)
+	return err
+}
+
+func TestCallStaticCollectsLogs(t *testing.T) {
+	evm := newMockEVMForTesting()
+	address := common.HexToAddress("0x010d")
+	precompile := makeTestPrecompile(t, `[
+		{"type":"function","name":"ping","stateMutability":"view","inputs":[],"outputs":[]},
+		{"type":"function","name":"register","stateMutability":"nonpayable","inputs":[],"outputs":[]},
+		{"type":"event","name":"Pinged","anonymous":false,"inputs":[
+			{"name":"value","type":"uint256","indexed":false}
+		]}
+	]`, &StaticCallTester{Address: address})
+
+	input := packTestCall(t, precompile, "Ping")
+	_, _, logs, err := precompile.CallStatic(input, address, common.Address{}, 1000000, evm)
+	Require(t, err)
+	if len(logs) != 1 || logs[0].Address != address {
+		Fail(t, "expected the simulation to collect the emitted log but got", logs)
+	}
+	if new(big.Int).SetBytes(logs[0].Data).Int64() != 7 {
+		Fail(t, "unexpected log data", logs[0].Data)
+	}
+
+	//nolint:errcheck
+	if persisted := evm.StateDB.(*state.StateDB).Logs(); len(persisted) != 0 {
+		Fail(t, "simulation added logs to the state", persisted)
+	}
+
+	// the simulation is strictly read-only, so methods that would write state revert
+	input = packTestCall(t, precompile, "Register")
+	if _, _, _, err := precompile.CallStatic(input, address, common.Address{}, 1000000, evm); !errors.Is(err, ErrReadOnlyCall) {
+		Fail(t, "expected a write method to be rejected but got", err)
+	}
+	size, err := testContext(common.Address{}, evm).State.AddressTable().Size()
+	Require(t, err)
+	if size != 0 {
+		Fail(t, "simulation mutated the state")
+	}
+}
+
+type BufferedEventTester struct {
+	Address      addr
+	Noted        func(ctx, mech, huge) error
+	NotedGasCost func(huge) (uint64, error)
+}
+
+func (con BufferedEventTester) Peek(c ctx, evm mech) error {
+	return con.Noted(c, evm, big.NewInt(1))
+}
+
+func (con BufferedEventTester) Poke(c ctx, evm mech) error {
+	return con.Noted(c, evm, big.NewInt(2))
+}
+
+func TestCallBufferedViewEvents(t *testing.T) {
+	evm := newMockEVMForTesting()
+	address := common.HexToAddress("0x0123")
+	precompile := makeTestPrecompile(t, `[
+		{"type":"function","name":"peek","stateMutability":"view","inputs":[],"outputs":[]},
+		{"type":"function","name":"poke","stateMutability":"nonpayable","inputs":[],"outputs":[]},
+		{"type":"event","name":"Noted","anonymous":false,"inputs":[
+			{"name":"value","type":"uint256","indexed":false}
+		]}
+	]`, &BufferedEventTester{Address: address})
+	statedb := evm.StateDB.(*state.StateDB) //nolint:errcheck
+
+	// a plain call can't emit from a view method
+	peek := packTestCall(t, precompile, "Peek")
+	_, _, err := callTestPrecompile(precompile, peek, evm)
+	if err == nil {
+		Fail(t, "a view method emitted an event during a plain call")
+	}
+
+	_, _, logs, err := precompile.CallBuffered(peek, address, address, common.Address{}, common.Big0, true, 1000000, evm)
+	Require(t, err)
+	if len(logs) != 1 || new(big.Int).SetBytes(logs[0].Data).Int64() != 1 {
+		Fail(t, "expected the view method's event to be buffered but got", logs)
+	}
+	if persisted := statedb.Logs(); len(persisted) != 0 {
+		Fail(t, "a view method persisted logs", persisted)
+	}
+
+	// write methods still persist their logs
+	poke := packTestCall(t, precompile, "Poke")
+	_, _, logs, err = precompile.CallBuffered(poke, address, address, common.Address{}, common.Big0, false, 1000000, evm)
+	Require(t, err)
+	if len(logs) != 0 {
+		Fail(t, "a write method's logs were buffered", logs)
+	}
+	if persisted := statedb.Logs(); len(persisted) != 1 || new(big.Int).SetBytes(persisted[0].Data).Int64() != 2 {
+		Fail(t, "expected the write method's event to be persisted but got", persisted)
+	}
+}
+
+type PricedTester struct {
+	Address addr
+}
+
+func (con PricedTester) Sum(c ctx, evm mech, values []huge) (huge, error) {
+	sum := new(big.Int)
+	for _, value := range values {
+		sum.Add(sum, value)
+	}
+	return sum, nil
+}
+
+func (con PricedTester) SumGasCost(values []huge) (uint64, error) {
+	if len(values) > 4 {
+		return 0, errors.New("array too long")
+	}
+	if len(values) == 3 {
+		return values[3].Uint64(), nil // the pricer panics, which mustn't crash the node
+	}
+	return 100 * uint64(len(values)), nil
+}
+
+func TestMethodGasCost(t *testing.T) {
+	evm := newMockEVMForTesting()
+	address := common.HexToAddress("0x0124")
+	precompile := makeTestPrecompile(t, `[
+		{"type":"function","name":"sum","stateMutability":"view","inputs":[{"name":"values","type":"uint256[]"}],"outputs":[{"name":"","type":"uint256"}]}
+	]`, &PricedTester{Address: address})
+
+	call := func(values []*big.Int) ([]byte, uint64, error) {
+		t.Helper()
+		input := packTestCall(t, precompile, "Sum", values)
+		return callTestPrecompile(precompile, input, evm)
+	}
+
+	_, oneLeft, err := call([]*big.Int{common.Big1})
+	Require(t, err)
+	_, twoLeft, err := call([]*big.Int{common.Big1, common.Big2})
+	Require(t, err)
+	// the second element costs 100 gas plus a word of calldata
+	if oneLeft-twoLeft != 100+params.CopyGas {
+		Fail(t, "unexpected gas difference", oneLeft-twoLeft)
+	}
+
+	output, _, err := call([]*big.Int{common.Big1, common.Big1, common.Big1, common.Big1, common.Big1})
+	if !errors.Is(err, vm.ErrExecutionReverted) || !bytes.Equal(output, revertReason("array too long")) {
+		Fail(t, "expected oversized input to be rejected but got", err, output)
+	}
+
+	output, _, err = call([]*big.Int{common.Big1, common.Big1, common.Big1})
+	if !errors.Is(err, vm.ErrExecutionReverted) || len(output) != 0 {
+		Fail(t, "expected the panicking pricer to revert but got", err, output)
+	}
+}
+
+type fakeMetricsSink struct {
+	calls    map[string]int
+	reverts  int
+	totalGas uint64
+}
+
+func (sink *fakeMetricsSink) RecordCall(contract, method string, gas uint64, reverted bool) {
+	sink.calls[contract+"."+method]++
+	sink.totalGas += gas
+	if reverted {
+		sink.reverts++
+	}
+}
+
+func TestMetricsSink(t *testing.T) {
+	evm := newMockEVMForTesting()
+	precompiles, err := Precompiles()
+	Require(t, err)
+	arbSys := precompiles[types.ArbSysAddress]
+	id := arbSys.Precompile().GetMethodID("ArbBlockNumber")
+
+	sink := &fakeMetricsSink{calls: make(map[string]int)}
+	SetMetricsSink(sink)
+	defer SetMetricsSink(nil)
+
+	for i := 0; i < 2; i++ {
+		_, _, err := arbSys.Call(id[:], types.ArbSysAddress, types.ArbSysAddress, common.Address{}, common.Big0, false, 1000000, evm)
+		Require(t, err)
+	}
+	_, _, err = arbSys.Call([]byte{0xde, 0xad, 0xbe, 0xef}, types.ArbSysAddress, types.ArbSysAddress, common.Address{}, common.Big0, false, 1000000, evm)
+	if err == nil {
+		Fail(t, "an unknown selector should revert")
+	}
+
+	if count := sink.calls["ArbSys.ArbBlockNumber"]; count != 2 {
+		Fail(t, "recorded", count, "calls to ArbBlockNumber", sink.calls)
+	}
+	if sink.reverts != 1 {
+		Fail(t, "recorded", sink.reverts, "reverts")
+	}
+	if sink.totalGas == 0 {
+		Fail(t, "recorded no gas")
+	}
+}
+
+type EnumTester struct {
+	Address addr
+	_       struct{} `enum:"SetMode.mode=3"`
+}
+
+func (con EnumTester) SetMode(c ctx, evm mech, mode uint8) (uint8, error) {
+	return mode, nil
+}
+
+type MisnamedEnumTester struct {
+	Address addr
+	_       struct{} `enum:"SetMode.kind=3"`
+}
+
+func (con MisnamedEnumTester) SetMode(c ctx, evm mech, mode uint8) (uint8, error) {
+	return mode, nil
+}
+
+type SenderTester struct {
+	Address addr
+}
+
+func (con SenderTester) WhoAsked(c ctx, evm mech) (addr, addr, uint64, error) {
+	sender := c.Sender()
+	return sender.Caller, sender.Origin, uint64(sender.Depth), nil
+}
+
+func TestSender(t *testing.T) {
+	evm := newMockEVMForTesting()
+	address := common.HexToAddress("0x0126")
+	precompile := makeTestPrecompile(t, `[
+		{"type":"function","name":"whoAsked","stateMutability":"view","inputs":[],"outputs":[
+			{"name":"caller","type":"address"},{"name":"origin","type":"address"},{"name":"depth","type":"uint64"}
+		]}
+	]`, &SenderTester{Address: address})
+
+	origin := common.HexToAddress("0x0a")
+	caller := common.HexToAddress("0x0b")
+	evm.TxContext.Origin = origin
+
+	input := packTestCall(t, precompile, "WhoAsked")
+	for _, depth := range []uint64{1, 2} {
+		// geth's hook enters a frame for the precompile, first for a transaction's call and then nested under a contract's
+		evm.IncrementDepth()
+		output, _, err := precompile.Call(input, address, address, caller, common.Big0, true, 1000000, evm)
+		Require(t, err)
+		results, err := precompile.methodsByName["WhoAsked"].template.Outputs.Unpack(output)
+		Require(t, err)
+		if results[0] != caller || results[1] != origin || results[2] != depth {
+			Fail(t, "unexpected sender", results)
+		}
+	}
+}
+
+type AddressListTester struct {
+	Address addr
+}
+
+func (con AddressListTester) Addresses(c ctx, evm mech) ([]addr, error) {
+	return []addr{common.HexToAddress("0x01"), common.HexToAddress("0x02"), common.HexToAddress("0x03")}, nil
+}
+
+func (con AddressListTester) RawAddresses(c ctx, evm mech) ([][20]byte, error) {
+	return [][20]byte{common.HexToAddress("0x01"), common.HexToAddress("0x02"), common.HexToAddress("0x03")}, nil
+}
+
+type NoAddressesTester struct {
+	Address addr
+}
+
+func (con NoAddressesTester) None(c ctx, evm mech) ([]addr, error) {
+	return nil, nil
+}
+
+type StringAddressTester struct {
+	Address addr
+}
+
+func (con StringAddressTester) Owner(c ctx, evm mech) (string, error) {
+	return "0x0000000000000000000000000000000000000001", nil
+}
+
+type DynamicOutputsTester struct {
+	Address addr
+}
+
+func (con DynamicOutputsTester) Both(c ctx, evm mech) ([]byte, string, error) {
+	return []byte("abc"), "xyz", nil
+}
+
+type FreeTester struct {
+	Address addr
+}
+
+func (con FreeTester) Free(c ctx, evm mech, value huge) error {
+	return nil
+}
+
+func (con FreeTester) FreeGasCost(value huge) (uint64, error) {
+	return 0, nil
+}
+
+func (con FreeTester) Priced(c ctx, evm mech, value huge) error {
+	return nil
+}
+
+func (con FreeTester) PricedGasCost(value huge) (uint64, error) {
+	return 100 + value.Uint64(), nil
+}
+
+func (con FreeTester) Unpriced(c ctx, evm mech) error {
+	return nil
+}
+
+func TestAuditGasCosts(t *testing.T) {
+	precompile := makeTestPrecompile(t, `[
+		{"type":"function","name":"free","stateMutability":"nonpayable","inputs":[{"name":"value","type":"uint256"}],"outputs":[]},
+		{"type":"function","name":"priced","stateMutability":"nonpayable","inputs":[{"name":"value","type":"uint256"}],"outputs":[]},
+		{"type":"function","name":"unpriced","stateMutability":"nonpayable","inputs":[],"outputs":[]}
+	]`, &FreeTester{Address: common.HexToAddress("0x012e")})
+
+	suspicious := AuditGasCosts(precompile)
+	if len(suspicious) != 1 || suspicious[0] != "Free" {
+		Fail(t, "expected only Free to be flagged but got", suspicious)
+	}
+}
+
+type revertRecord struct {
+	contract, method string
+	caller           addr
+	reason           error
+}
+
+type capturingRevertLogger struct {
+	reverts []revertRecord
+}
+
+func (logger *capturingRevertLogger) LogRevert(contract, method string, caller common.Address, reason error) {
+	logger.reverts = append(logger.reverts, revertRecord{contract, method, caller, reason})
+}
+
+func TestRevertLogger(t *testing.T) {
+	evm := newMockEVMForTestingAtArbosVersion(t, arbostypes.ArbosVersion_PrecompileRevertReasons)
+	address := common.HexToAddress("0x0131")
+	precompile := makeTestPrecompile(t, `[
+		{"type":"function","name":"check","stateMutability":"nonpayable","inputs":[],"outputs":[]},
+		{"type":"function","name":"deposit","stateMutability":"payable","inputs":[],"outputs":[{"name":"","type":"uint256"}]}
+	]`, &NilValueTester{Address: address})
+
+	logger := &capturingRevertLogger{}
+	SetRevertLogger(logger)
+	defer SetRevertLogger(nil)
+
+	caller := common.HexToAddress("0x0f")
+	input := packTestCall(t, precompile, "Check")
+	_, _, err := precompile.Call(input, address, address, caller, common.Big1, false, 1000000, evm)
+	if !errors.Is(err, ErrNonPayableValue) {
+		Fail(t, "expected a non-payable revert but got", err)
+	}
+	input = packTestCall(t, precompile, "Deposit")
+	_, _, err = precompile.Call(input, address, address, caller, common.Big0, false, 1000000, evm)
+	Require(t, err)
+
+	if len(logger.reverts) != 1 {
+		Fail(t, "expected exactly one revert to be logged but got", logger.reverts)
+	}
+	record := logger.reverts[0]
+	if record.contract != "NilValueTester" || record.method != "Check" || record.caller != caller {
+		Fail(t, "the revert was attributed to the wrong call", record)
+	}
+	if !errors.Is(record.reason, ErrNonPayableValue) || !strings.Contains(record.reason.Error(), "precompile: non-payable") {
+		Fail(t, "unexpected revert reason", record.reason)
+	}
+}
+
+type EventsOnlyTester struct {
+	Address          addr
+	Announced        func(ctx, mech, addr, huge) error
+	AnnouncedGasCost func(addr, huge) (uint64, error)
+}
+
+func TestEventsOnlyPrecompile(t *testing.T) {
+	evm := newMockEVMForTesting()
+	address := common.HexToAddress("0x0135")
+	impl := &EventsOnlyTester{Address: address}
+	precompile := makeTestPrecompile(t, `[
+		{"type":"event","name":"Announced","anonymous":false,"inputs":[
+			{"name":"who","type":"address","indexed":true},
+			{"name":"amount","type":"uint256","indexed":false}
+		]}
+	]`, impl)
+	if len(precompile.methods) != 0 {
+		Fail(t, "an events-only precompile shouldn't have methods", precompile.methods)
+	}
+
+	who := common.HexToAddress("0xbeef")
+	Require(t, impl.Announced(testContext(common.Address{}, evm), evm, who, big.NewInt(7)))
+
+	//nolint:errcheck
+	logs := evm.StateDB.(*state.StateDB).Logs()
+	if len(logs) != 1 || logs[0].Topics[0] != precompile.events["Announced"].template.ID {
+		Fail(t, "expected an Announced event but got", logs)
+	}
+	if logs[0].Topics[1] != common.BytesToHash(who.Bytes()) {
+		Fail(t, "the event names the wrong address", logs[0].Topics[1])
+	}
+
+	for _, input := range [][]byte{nil, {0x12, 0x34, 0x56, 0x78}} {
+		_, _, err := callTestPrecompile(precompile, input, evm)
+		if !errors.Is(err, vm.ErrExecutionReverted) {
+			Fail(t, "calling an events-only precompile should revert but got", err)
+		}
+	}
+}
+
+type AnonymousEventTester struct {
+	Address        addr
+	Whisper        func(ctx, mech, huge, huge) error
+	WhisperGasCost func(huge, huge) (uint64, error)
+}
+
+func TestAnonymousEvents(t *testing.T) {
+	evm := newMockEVMForTesting()
+	impl := &AnonymousEventTester{Address: common.HexToAddress("0x010e")}
+	makeTestPrecompile(t, `[
+		{"type":"event","name":"Whisper","anonymous":true,"inputs":[
+			{"name":"key","type":"uint256","indexed":true},
+			{"name":"value","type":"uint256","indexed":false}
+		]}
+	]`, impl)
+
+	Require(t, impl.Whisper(testContext(common.Address{}, evm), evm, big.NewInt(3), big.NewInt(4)))
+
+	//nolint:errcheck
+	logs := evm.StateDB.(*state.StateDB).Logs()
+	if len(logs) != 1 || len(logs[0].Topics) != 1 {
+		Fail(t, "anonymous event shouldn't include its signature as a topic", logs)
+	}
+	if logs[0].Topics[0] != common.BigToHash(big.NewInt(3)) {
+		Fail(t, "unexpected topic", logs[0].Topics[0])
+	}
+
+	cost, err := impl.WhisperGasCost(big.NewInt(3), big.NewInt(4))
+	Require(t, err)
+	if cost != params.LogGas+params.LogTopicGas+32*params.LogDataGas {
+		Fail(t, "anonymous event mispriced", cost)
+	}
+}
+
+type recordEntry struct {
+	Amount huge
+	Owner  addr
+}
+
+type TupleEventTester struct {
+	Address         addr
+	Recorded        func(ctx, mech, uint64, recordEntry) error
+	RecordedGasCost func(uint64, recordEntry) (uint64, error)
+}
+
+func TestTupleEventData(t *testing.T) {
+	evm := newMockEVMForTesting()
+	impl := &TupleEventTester{Address: common.HexToAddress("0x0129")}
+	precompile := makeTestPrecompile(t, `[
+		{"type":"event","name":"Recorded","anonymous":false,"inputs":[
+			{"name":"id","type":"uint64","indexed":true},
+			{"name":"entry","type":"tuple","indexed":false,"components":[
+				{"name":"amount","type":"uint256"},{"name":"owner","type":"address"}
+			]}
+		]}
+	]`, impl)
+
+	entry := recordEntry{Amount: big.NewInt(42), Owner: common.HexToAddress("0x0a")}
+	Require(t, impl.Recorded(testContext(common.Address{}, evm), evm, 7, entry))
+
+	//nolint:errcheck
+	logs := evm.StateDB.(*state.StateDB).Logs()
+	if len(logs) != 1 || len(logs[0].Data) != 64 {
+		Fail(t, "expected a log with the tuple's two words of data but got", logs)
+	}
+	event := precompile.events["Recorded"]
+	values, err := event.template.Inputs.NonIndexed().Unpack(logs[0].Data)
+	Require(t, err)
+	decoded, ok := abi.ConvertType(values[0], new(recordEntry)).(*recordEntry)
+	if !ok || decoded.Amount.Cmp(entry.Amount) != 0 || decoded.Owner != entry.Owner {
+		Fail(t, "decoded", values[0], "instead of", entry)
+	}
+
+	cost, err := impl.RecordedGasCost(7, entry)
+	Require(t, err)
+	if cost != params.LogGas+2*params.LogTopicGas+64*params.LogDataGas {
+		Fail(t, "tuple event mispriced", cost)
+	}
+}
+
+type TopicHeavyTester struct {
+	Address     addr
+	Busy        func(ctx, mech, huge, huge, huge, huge) error
+	BusyGasCost func(huge, huge, huge, huge) (uint64, error)
+}
+
+func TestEventTopicLimit(t *testing.T) {
+	event := func(anonymous bool) string {
+		return `[{"type":"event","name":"Busy","anonymous":` + strconv.FormatBool(anonymous) + `,"inputs":[
+			{"name":"a","type":"uint256","indexed":true},
+			{"name":"b","type":"uint256","indexed":true},
+			{"name":"c","type":"uint256","indexed":true},
+			{"name":"d","type":"uint256","indexed":true}
+		]}]`
+	}
+	_, _, err := MakePrecompile(&bind.MetaData{ABI: event(false)}, &TopicHeavyTester{})
+	if err == nil || !strings.Contains(err.Error(), "indexed inputs") {
+		Fail(t, "expected an event with 4 indexed inputs and a signature topic to be rejected but got", err)
+	}
+
+	// anonymous events have no signature topic, leaving room for a 4th index
+	_, _, err = MakePrecompile(&bind.MetaData{ABI: event(true)}, &TopicHeavyTester{})
+	Require(t, err)
+}
+
+type BoolTopicTester struct {
+	Address        addr
+	Toggled        func(ctx, mech, bool) error
+	ToggledGasCost func(bool) (uint64, error)
+}
+
+type GatedEventTester struct {
+	Address         addr
+	Upgraded        func(ctx, mech, huge) error
+	UpgradedGasCost func(huge) (uint64, error)
+}
+
+func TestEventArbosVersions(t *testing.T) {
+	evm := newMockEVMForTesting()
+	impl := &GatedEventTester{Address: common.HexToAddress("0x0134")}
+	precompile := makeTestPrecompile(t, `[
+		{"type":"event","name":"Upgraded","anonymous":false,"inputs":[
+			{"name":"value","type":"uint256","indexed":false}
+		]}
+	]`, impl)
+	if err := precompile.SetEventArbosVersions(map[string]uint64{"Missing": 1}); err == nil {
+		Fail(t, "gated a nonexistent event")
+	}
+
+	//nolint:errcheck
+	statedb := evm.StateDB.(*state.StateDB)
+	current := arbosState.ArbOSVersion(statedb)
+	context := testContext(common.Address{}, evm)
+
+	// before activation, emitting does nothing and costs nothing
+	Require(t, precompile.SetEventArbosVersions(map[string]uint64{"Upgraded": current + 1}))
+	Require(t, impl.Upgraded(context, evm, big.NewInt(1)))
+	if logs := statedb.Logs(); len(logs) != 0 {
+		Fail(t, "an inactive event was emitted", logs)
+	}
+	if context.Burned() != 0 {
+		Fail(t, "an inactive event burned gas", context.Burned())
+	}
+
+	Require(t, precompile.SetEventArbosVersions(map[string]uint64{"Upgraded": current}))
+	Require(t, impl.Upgraded(context, evm, big.NewInt(2)))
+	if logs := statedb.Logs(); len(logs) != 1 || new(big.Int).SetBytes(logs[0].Data).Int64() != 2 {
+		Fail(t, "expected the activated event to be emitted but got", logs)
+	}
+}
+
+func TestNegativeIndexedTopics(t *testing.T) {
+	allOnes := common.Hash{}
+	for i := range allOnes {
+		allOnes[i] = 0xff
+	}
+	for _, kind := range []string{"int256", "int64", "int8"} {
+		intType, err := abi.NewType(kind, "", nil)
+		Require(t, err)
+		var value interface{} = big.NewInt(-1)
+		switch kind {
+		case "int64":
+			value = int64(-1)
+		case "int8":
+			value = int8(-1)
+		}
+		topic, err := eventTopic(abi.Argument{Name: "value", Type: intType, Indexed: true}, value)
+		Require(t, err)
+		if topic != allOnes {
+			Fail(t, "indexed", kind, "of -1 wasn't sign extended", topic)
+		}
+	}
+}
+
+type IndexedArrayTester struct {
+	Address       addr
+	Listed        func(ctx, mech, []addr, huge) error
+	ListedGasCost func([]addr, huge) (uint64, error)
+}
+
+type EmitThenRevertTester struct {
+	Address          addr
+	Attempted        func(ctx, mech, huge) error
+	AttemptedGasCost func(huge) (uint64, error)
+}
+
+func (con EmitThenRevertTester) Attempt(c ctx, evm mech) error {
+	if err := con.Attempted(c, evm, big.NewInt(1)); err != nil {
+		return err
+	}
+	return errors.New("attempt failed")
+}
+
+func TestRevertDiscardsLogs(t *testing.T) {
+	evm := newMockEVMForTesting()
+	address := common.HexToAddress("0x011f")
+	precompile := makeTestPrecompile(t, `[
+		{"type":"function","name":"attempt","stateMutability":"nonpayable","inputs":[],"outputs":[]},
+		{"type":"event","name":"Attempted","anonymous":false,"inputs":[
+			{"name":"value","type":"uint256","indexed":false}
+		]}
+	]`, &EmitThenRevertTester{Address: address})
+
+	//nolint:errcheck
+	statedb := evm.StateDB.(*state.StateDB)
+
+	// the evm snapshots the state around each call, reverting to it when the call fails
+	snapshot := statedb.Snapshot()
+	input := packTestCall(t, precompile, "Attempt")
+	_, _, err := callTestPrecompile(precompile, input, evm)
+	if !errors.Is(err, vm.ErrExecutionReverted) {
+		Fail(t, "expected the attempt to revert but got", err)
+	}
+	if len(statedb.Logs()) != 1 {
+		Fail(t, "the log should be journaled until the revert", statedb.Logs())
+	}
+	statedb.RevertToSnapshot(snapshot)
+	if len(statedb.Logs()) != 0 {
+		Fail(t, "reverting didn't discard the log", statedb.Logs())
+	}
+}
+
+func TestEventGasCharged(t *testing.T) {
+	evm := newMockEVMForTesting()
+	impl := &IndexedStringTester{Address: common.HexToAddress("0x0102")}
+	makeTestPrecompile(t, namedEventABI, impl)
+
+	cost, err := impl.NamedGasCost("hello", []byte{}, big.NewInt(1))
+	Require(t, err)
+
+	// emitting burns exactly what the LOG opcode would
+	context := testContext(common.Address{}, evm)
+	Require(t, impl.Named(context, evm, "hello", []byte{}, big.NewInt(1)))
+	if context.Burned() != cost {
+		Fail(t, "emitting burned", context.Burned(), "instead of", cost)
+	}
+
+	// emitting without enough gas fails and doesn't log
+	context = testContext(common.Address{}, evm)
+	context.gasSupplied = cost - 1
+	context.gasLeft = cost - 1
+	err = impl.Named(context, evm, "hello", []byte{}, big.NewInt(1))
+	if !errors.Is(err, vm.ErrOutOfGas) {
+		Fail(t, "expected out of gas but got", err)
+	}
+
+	//nolint:errcheck
+	logs := evm.StateDB.(*state.StateDB).Logs()
+	if len(logs) != 1 {
+		Fail(t, "expected only the paid-for log but have", len(logs))
+	}
+}
+
+func TestDelegatecall(t *testing.T) {
+	evm := newMockEVMForTestingAtArbosVersion(t, arbostypes.ArbosVersion_PrecompileDelegatecallGuard)
+	precompiles, err := Precompiles()
+	Require(t, err)
+	arbSys := precompiles[types.ArbSysAddress].Precompile()
+	proxy := common.HexToAddress("0x0103")
+
+	// even pure methods revert when delegatecalled
+	input := packTestCall(t, arbSys, "MapL1SenderContractAddressToL2Alias", proxy, proxy)
+	_, _, err = arbSys.Call(input, types.ArbSysAddress, proxy, proxy, common.Big0, false, 1000000, evm)
+	if !errors.Is(err, ErrDelegatecall) {
+		Fail(t, "expected delegatecall to revert but got", err)
+	}
+
+	// older ArbOS versions let them through, as they always have
+	oldEVM := newMockEVMForTestingAtArbosVersion(t, arbostypes.ArbosVersion_PrecompileDelegatecallGuard-1)
+	_, _, err = arbSys.Call(input, types.ArbSysAddress, proxy, proxy, common.Big0, false, 1000000, oldEVM)
+	Require(t, err)
+
+	// newer versions let them through only if the precompile opts in
+	arbSys.SetAllowDelegatecall(true)
+	_, _, err = arbSys.Call(input, types.ArbSysAddress, proxy, proxy, common.Big0, false, 1000000, evm)
+	Require(t, err)
+
+	// though impure methods still revert
+	input = packTestCall(t, arbSys, "ArbBlockNumber")
+	_, _, err = arbSys.Call(input, types.ArbSysAddress, proxy, proxy, common.Big0, false, 1000000, evm)
+	if !errors.Is(err, vm.ErrExecutionReverted) {
+		Fail(t, "expected impure delegatecall to revert but got", err)
+	}
+}
+
+type GuardTester struct {
+	Address addr
+}
+
+func (con GuardTester) Peek(c ctx, evm mech) error {
+	return nil
+}
+
+func (con GuardTester) Poke(c ctx, evm mech) error {
+	return nil
+}
+
+func TestGuardRevertReasons(t *testing.T) {
+	evm := newMockEVMForTestingAtArbosVersion(t, arbostypes.ArbosVersion_PrecompileRevertReasons)
+	address := common.HexToAddress("0x0119")
+	proxy := common.HexToAddress("0x011a")
+	precompile := makeTestPrecompile(t, `[
+		{"type":"function","name":"peek","stateMutability":"view","inputs":[],"outputs":[]},
+		{"type":"function","name":"poke","stateMutability":"nonpayable","inputs":[],"outputs":[]}
+	]`, &GuardTester{Address: address})
+	precompile.SetAllowDelegatecall(true)
+
+	expectRevert := func(name string, actingAs addr, value huge, readOnly bool, sentinel error, expected string) {
+		t.Helper()
+		input := packTestCall(t, precompile, name)
+		output, _, err := precompile.Call(input, address, actingAs, proxy, value, readOnly, 1000000, evm)
+		if !errors.Is(err, vm.ErrExecutionReverted) || !errors.Is(err, sentinel) {
+			Fail(t, "expected a revert with", sentinel, "but got", err)
+		}
+		reason, err := abi.UnpackRevert(output)
+		Require(t, err)
+		if reason != expected {
+			Fail(t, "unexpected revert reason", reason, "instead of", expected)
+		}
+	}
+
+	expectRevert("Peek", proxy, common.Big0, false, ErrNotActingAsSelf, "precompile: not acting as self")
+	expectRevert("Poke", address, common.Big0, true, ErrReadOnlyCall, "precompile: read-only")
+	expectRevert("Poke", address, big.NewInt(1), false, ErrNonPayableValue, "precompile: non-payable")
+
+	// older versions revert without a reason
+	evm = newMockEVMForTestingAtArbosVersion(t, arbostypes.ArbosVersion_PrecompileRevertReasons-1)
+	input := packTestCall(t, precompile, "Peek")
+	output, _, err := precompile.Call(input, address, proxy, proxy, common.Big0, false, 1000000, evm)
+	if !errors.Is(err, ErrNotActingAsSelf) || len(output) != 0 {
+		Fail(t, "expected a revert without data", output, err)
+	}
+}
+
+type MeteredTester struct {
+	Address addr
+}
+
+func (con MeteredTester) Work(c ctx, evm mech, units uint64) error {
+	return c.Burn(100 * units)
+}
+
+func TestDynamicGas(t *testing.T) {
+	evm := newMockEVMForTesting()
+	address := common.HexToAddress("0x0104")
+	precompile := makeTestPrecompile(t, `[
+		{"type":"function","name":"work","stateMutability":"nonpayable",
+		 "inputs":[{"name":"units","type":"uint64"}],"outputs":[]}
+	]`, &MeteredTester{Address: address})
+
+	// handlers meter work that isn't known until execution
+	input := packTestCall(t, precompile, "Work", uint64(10))
+	_, gasLeft, err := callTestPrecompile(precompile, input, evm)
+	Require(t, err)
+	burned := 1000000 - gasLeft
+	expected := storage.StorageReadCost + params.CopyGas + 100*10
+	if burned != expected {
+		Fail(t, "burned", burned, "instead of", expected)
+	}
+
+	// running out of gas reverts
+	input = packTestCall(t, precompile, "Work", uint64(1e9))
+	_, gasLeft, err = callTestPrecompile(precompile, input, evm)
+	if err == nil || gasLeft != 0 {
+		Fail(t, "expected an out-of-gas revert but have", err, gasLeft)
+	}
+}
+
+type RawOutputTester struct {
+	Address addr
+}
+
+func (con RawOutputTester) Pair(c ctx, evm mech) (RawBytes, error) {
+	return append(common.BigToHash(big.NewInt(1)).Bytes(), common.BigToHash(big.NewInt(2)).Bytes()...), nil
+}
+
+func TestHandlerOutputs(t *testing.T) {
+	evm := newMockEVMForTesting()
+	evm.Context.BlockNumber = big.NewInt(77)
+	address := common.HexToAddress("0x0107")
+	overloadABI := `[
+		{"type":"function","name":"describe","stateMutability":"pure",
+		 "inputs":[{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"string"}]},
+		{"type":"function","name":"describe","stateMutability":"pure",
+		 "inputs":[{"name":"account","type":"address"},{"name":"flag","type":"bool"}],
+		 "outputs":[{"name":"","type":"string"}]},
+		{"type":"function","name":"unique","stateMutability":"pure","inputs":[],"outputs":[{"name":"","type":"string"}]}
+	]`
+	addressListABI := `[
+		{"type":"function","name":"addresses","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address[]"}]},
+		{"type":"function","name":"rawAddresses","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address[]"}]}
+	]`
+	fixedBytesABI := `[
+		{"type":"function","name":"digest","stateMutability":"pure","inputs":[],"outputs":[{"name":"","type":"bytes32"}]},
+		{"type":"function","name":"selector","stateMutability":"pure","inputs":[],"outputs":[{"name":"","type":"bytes4"}]}
+	]`
+	boolABI := `[
+		{"type":"function","name":"no","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"bool"}]},
+		{"type":"function","name":"yes","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"bool"}]}
+	]`
+
+	addresses := []common.Address{common.HexToAddress("0x01"), common.HexToAddress("0x02"), common.HexToAddress("0x03")}
+	uint128Max := new(big.Int).Sub(new(big.Int).Lsh(common.Big1, 128), common.Big1)
+	int128Magnitude := new(big.Int).Lsh(common.Big1, 127) // the handler negates it to int128's minimum
+	wide := new(big.Int).Lsh(common.Big1, 200)
+	key := [4]*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3), big.NewInt(4)}
+	var scaled [4]*big.Int
+	for i := range key {
+		scaled[i] = new(big.Int).Lsh(key[i], 200)
+	}
+	// a function value is the contract's address followed by the method's selector
+	var callback [24]byte
+	copy(callback[:], common.HexToAddress("0xc0ffee").Bytes())
+	copy(callback[20:], []byte{0xde, 0xad, 0xbe, 0xef})
+
+	cases := []struct {
+		abi         string
+		implementer interface{}
+		method      string
+		args        []interface{}
+		outputs     []interface{} // what the method's outputs should encode
+	}{
+		// raw output is returned as is, rather than being re-encoded
+		{`[
+			{"type":"function","name":"pair","stateMutability":"view","inputs":[],
+			 "outputs":[{"name":"a","type":"uint256"},{"name":"b","type":"uint256"}]}
+		]`, &RawOutputTester{Address: address}, "Pair", nil, []interface{}{big.NewInt(1), big.NewInt(2)}},
+		{`[
+			{"type":"function","name":"triple","stateMutability":"view","inputs":[],"outputs":[
+				{"name":"amount","type":"uint256"},{"name":"account","type":"address"},{"name":"flag","type":"bool"}
+			]}
+		]`, &StructOutputTester{Address: address}, "Triple", nil, []interface{}{big.NewInt(42), common.HexToAddress("0x0a"), true}},
+		{`[
+			{"type":"function","name":"double","stateMutability":"pure",
+			 "inputs":[{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"uint256"}]}
+		]`, &ContextFreeTester{Address: address}, "Double", []interface{}{big.NewInt(21)}, []interface{}{big.NewInt(42)}},
+		{`[
+			{"type":"function","name":"describe","stateMutability":"view","inputs":[],
+			 "outputs":[{"name":"","type":"uint256"},{"name":"","type":"uint256"}]}
+		]`, &EnvironmentTester{Address: address}, "Describe", nil, []interface{}{big.NewInt(77), evm.ChainConfig().ChainID}},
+		{overloadABI, &OverloadTester{Address: address}, "Describe_uint256", []interface{}{big.NewInt(1)}, []interface{}{"number"}},
+		{overloadABI, &OverloadTester{Address: address}, "Describe_address_bool", []interface{}{address, true}, []interface{}{"account"}},
+		{overloadABI, &OverloadTester{Address: address}, "Unique", nil, []interface{}{"unique"}},
+		{addressListABI, &AddressListTester{Address: address}, "Addresses", nil, []interface{}{addresses}},
+		{addressListABI, &AddressListTester{Address: address}, "RawAddresses", nil, []interface{}{addresses}},
+		// an empty dynamic array is its offset followed by a zero length
+		{`[
+			{"type":"function","name":"none","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address[]"}]}
+		]`, &NoAddressesTester{Address: address}, "None", nil, []interface{}{[]common.Address{}}},
+		// two head words of offsets, then each tail's length and padded contents
+		{`[
+			{"type":"function","name":"both","stateMutability":"view","inputs":[],"outputs":[
+				{"name":"data","type":"bytes"},{"name":"text","type":"string"}
+			]}
+		]`, &DynamicOutputsTester{Address: address}, "Both", nil, []interface{}{[]byte("abc"), "xyz"}},
+		{fixedBytesABI, &FixedBytesTester{Address: address}, "Digest", nil, []interface{}{common.HexToHash("0x0102")}},
+		{fixedBytesABI, &FixedBytesTester{Address: address}, "Selector", nil, []interface{}{bytes4{0xde, 0xad, 0xbe, 0xef}}},
+		{boolABI, &BoolOutputTester{Address: address}, "No", nil, []interface{}{false}},
+		{boolABI, &BoolOutputTester{Address: address}, "Yes", nil, []interface{}{true}},
+		{widthsABI, &WidthTester{Address: address}, "Echo", []interface{}{uint8(255), uint64(1) << 63, wide},
+			[]interface{}{uint8(255), uint64(1) << 63, wide}},
+		{fixedArrayABI, &FixedArrayTester{Address: address}, "Scale", []interface{}{key}, []interface{}{scaled}},
+		{forwardABI, &FunctionArgTester{Address: address}, "Forward", []interface{}{callback}, []interface{}{callback}},
+		{overflowABI, &OverflowTester{Address: address}, "Narrow", []interface{}{uint128Max}, []interface{}{uint128Max}},
+		{overflowABI, &OverflowTester{Address: address}, "Signed", []interface{}{int128Magnitude}, []interface{}{new(big.Int).Neg(int128Magnitude)}},
+		{enumABI, &EnumTester{Address: address}, "SetMode", []interface{}{uint8(0)}, []interface{}{uint8(0)}},
+		{enumABI, &EnumTester{Address: address}, "SetMode", []interface{}{uint8(1)}, []interface{}{uint8(1)}},
+		{enumABI, &EnumTester{Address: address}, "SetMode", []interface{}{uint8(2)}, []interface{}{uint8(2)}},
+	}
+	for _, test := range cases {
+		precompile := makeTestPrecompile(t, test.abi, test.implementer)
+		output, _, err := callTestPrecompile(precompile, packTestCall(t, precompile, test.method, test.args...), evm)
+		Require(t, err, test.method)
+		expected, err := precompile.methodsByName[test.method].template.Outputs.Pack(test.outputs...)
+		Require(t, err)
+		if !bytes.Equal(output, expected) {
+			Fail(t, precompile.name+"."+test.method, "returned", output, "instead of", expected)
+		}
+	}
+
+	// handlers taking an environment can be tested without an evm
+	number, _, err := EnvironmentTester{}.Describe(nil, &Environment{BlockNumber: big.NewInt(5)})
+	Require(t, err)
+	if number.Int64() != 5 {
+		Fail(t, "unexpected block number", number)
+	}
+}
+
+type StructOutputTester struct {
+	Address addr
+}
+
+type structOutputTriple struct {
+	Amount  huge
+	Account addr
+	Flag    bool
+}
+
+func (con StructOutputTester) Triple(c ctx, evm mech) (structOutputTriple, error) {
+	return structOutputTriple{big.NewInt(42), common.HexToAddress("0x0a"), true}, nil
+}
+
+func TestSimulate(t *testing.T) {
+	evm := newMockEVMForTesting()
+	precompiles, err := Precompiles()
+	Require(t, err)
+	table := precompiles[common.HexToAddress("66")].Precompile()
+	input := packTestCall(t, table, "Register", common.HexToAddress("0x21"))
+
+	_, gasUsed, err := table.Simulate(input, evm, 1000000)
+	Require(t, err)
+	if gasUsed == 0 {
+		Fail(t, "simulating a registration should use gas")
+	}
+
+	size, err := testContext(common.Address{}, evm).State.AddressTable().Size()
+	Require(t, err)
+	if size != 0 {
+		Fail(t, "simulation mutated the state")
+	}
+
+	if _, _, err := table.Simulate(input, evm, gasUsed-1); err == nil {
+		Fail(t, "expected the simulation to fail under a lower gas cap")
+	}
+}
+
+type FallbackTester struct {
+	Address addr
+}
+
+func (con FallbackTester) Known(c ctx, evm mech) error {
+	return nil
+}
+
+func (con FallbackTester) Fallback(c ctx, evm mech, input []byte) ([]byte, error) {
+	return append([]byte{0xfb}, input...), nil
+}
+
+func TestFallback(t *testing.T) {
+	evm := newMockEVMForTesting()
+	address := common.HexToAddress("0x010f")
+	precompile := makeTestPrecompile(t, `[
+		{"type":"function","name":"known","stateMutability":"nonpayable","inputs":[],"outputs":[]}
+	]`, &FallbackTester{Address: address})
+
+	call := func(input []byte) []byte {
+		t.Helper()
+		output, _, err := callTestPrecompile(precompile, input, evm)
+		Require(t, err)
+		return output
+	}
+
+	if output := call(packTestCall(t, precompile, "Known")); len(output) != 0 {
+		Fail(t, "known selector was sent to the fallback", output)
+	}
+	for _, input := range [][]byte{{0xde, 0xad, 0xbe, 0xef, 0x01}, {0x02}} {
+		if output := call(input); !bytes.Equal(output, append([]byte{0xfb}, input...)) {
+			Fail(t, "fallback didn't receive the raw calldata", output)
+		}
+	}
+}
+
+type ContextFreeTester struct {
+	Address addr
+}
+
+func (con ContextFreeTester) Double(value huge) (huge, error) {
+	return new(big.Int).Lsh(value, 1), nil
+}
+
+type PanicTester struct {
+	Address addr
+}
+
+func (con PanicTester) Explode(c ctx, evm mech) (huge, error) {
+	var values []huge
+	return values[1], nil
+}
+
+func TestHandlerPanicsRevert(t *testing.T) {
+	evm := newMockEVMForTestingAtArbosVersion(t, arbostypes.ArbosVersion_PrecompileRevertReasons)
+	address := common.HexToAddress("0x0113")
+	precompile := makeTestPrecompile(t, `[
+		{"type":"function","name":"explode","stateMutability":"view",
+		 "inputs":[],"outputs":[{"name":"","type":"uint256"}]}
+	]`, &PanicTester{Address: address})
+
+	input := packTestCall(t, precompile, "Explode")
+	output, gasLeft, err := callTestPrecompile(precompile, input, evm)
+	if !errors.Is(err, vm.ErrExecutionReverted) {
+		Fail(t, "expected the panic to revert but got", err)
+	}
+	reason, err := abi.UnpackRevert(output)
+	Require(t, err)
+	if reason != "ArbOS: internal precompile error" {
+		Fail(t, "unexpected revert reason", reason)
+	}
+	if gasLeft == 0 {
+		Fail(t, "a panicking handler shouldn't consume all gas")
+	}
+
+	// older ArbOS versions revert without data, consuming all gas
+	evm = newMockEVMForTestingAtArbosVersion(t, arbostypes.ArbosVersion_PrecompileRevertReasons-1)
+	output, gasLeft, err = callTestPrecompile(precompile, input, evm)
+	if !errors.Is(err, vm.ErrExecutionReverted) || len(output) != 0 || gasLeft != 0 {
+		Fail(t, "unexpected revert on an old ArbOS version", output, gasLeft, err)
+	}
+}
+
+type OverloadTester struct {
+	Address addr
+}
+
+func (con OverloadTester) Describe_uint256(c ctx, value huge) (string, error) {
+	return "number", nil
+}
+
+func (con OverloadTester) Describe_address_bool(c ctx, account addr, flag bool) (string, error) {
+	return "account", nil
+}
+
+func (con OverloadTester) Unique(c ctx) (string, error) {
+	return "unique", nil
+}
+
+type EncodingFailureTester struct {
+	Address addr
+}
+
+func (con EncodingFailureTester) Broken(c ctx, evm mech) error {
+	return newEncodingError("event Broken", errors.New("bad value"))
+}
+
+func TestPrecompileEvents(t *testing.T) {
+	precompiles, err := Precompiles()
+	Require(t, err)
+	debug := precompiles[common.HexToAddress("ff")].Precompile()
+
+	names := []string{}
+	for _, event := range debug.Events() {
+		if event.ID != crypto.Keccak256Hash([]byte(event.Sig)) {
+			Fail(t, "event", event.Name, "has the wrong ID")
+		}
+		names = append(names, event.Name)
+	}
+	if !sort.StringsAreSorted(names) || len(names) != len(debug.events) {
+		Fail(t, "unexpected events", names)
+	}
+	if len(names) == 0 || names[0] != "Basic" {
+		Fail(t, "ArbDebug's events are missing", names)
+	}
+}
+
+type EchoTester struct {
+	Address addr
+}
+
+func (con EchoTester) Echo(data []byte) ([]byte, error) {
+	return data, nil
+}
+
+func TestMaxInputSize(t *testing.T) {
+	evm := newMockEVMForTesting()
+	address := common.HexToAddress("0x0116")
+	precompile := makeTestPrecompile(t, `[
+		{"type":"function","name":"echo","stateMutability":"pure",
+		 "inputs":[{"name":"data","type":"bytes"}],"outputs":[{"name":"","type":"bytes"}]}
+	]`, &EchoTester{Address: address})
+
+	call := func(data []byte) ([]byte, error) {
+		input := packTestCall(t, precompile, "Echo", data)
+		output, _, err := callTestPrecompile(precompile, input, evm)
+		return output, err
+	}
+
+	// by default there is no limit
+	_, err := call(make([]byte, 4096))
+	Require(t, err)
+
+	precompile.SetMaxInputSize(256)
+	_, err = call(make([]byte, 128))
+	Require(t, err)
+
+	output, err := call(make([]byte, 4096))
+	if !errors.Is(err, vm.ErrExecutionReverted) {
+		Fail(t, "expected oversized input to revert but got", err)
+	}
+	reason, err := abi.UnpackRevert(output)
+	Require(t, err)
+	if reason != "ArbOS: input too large" {
+		Fail(t, "unexpected revert reason", reason)
+	}
+}
+
+func TestPrecompilesSorted(t *testing.T) {
+	first, err := PrecompilesSorted()
+	Require(t, err)
+	second, err := PrecompilesSorted()
+	Require(t, err)
+
+	precompiles, err := Precompiles()
+	Require(t, err)
+	if len(first) != len(precompiles) || len(second) != len(first) {
+		Fail(t, "sorted precompiles are missing entries")
+	}
+	for i, entry := range first {
+		if i > 0 && bytes.Compare(first[i-1].Addr[:], entry.Addr[:]) >= 0 {
+			Fail(t, "precompiles aren't sorted by address", first[i-1].Addr, entry.Addr)
+		}
+		if second[i].Addr != entry.Addr || second[i].Name != entry.Name {
+			Fail(t, "sorted order isn't stable", entry.Name, second[i].Name)
+		}
+		if entry.Name != entry.P.Precompile().Name() {
+			Fail(t, "mismatched name", entry.Name)
+		}
+	}
+}
+
+func TestPrecompileOptions(t *testing.T) {
+	standard, err := Precompiles()
+	Require(t, err)
+
+	debugAddress := common.HexToAddress("ff")
+	blsAddress := common.HexToAddress("67")
+	customBLS := makeTestPrecompile(t, touchABI, &VoidTester{Address: blsAddress})
+
+	custom, err := Precompiles(WithoutPrecompile(debugAddress), WithOverride(blsAddress, customBLS))
+	Require(t, err)
+	if _, ok := standard[debugAddress]; !ok {
+		Fail(t, "the default set should include ArbDebug")
+	}
+	if _, ok := custom[debugAddress]; ok {
+		Fail(t, "ArbDebug wasn't excluded")
+	}
+	if custom[blsAddress] != customBLS {
+		Fail(t, "ArbBLS wasn't overridden")
+	}
+	if len(custom) != len(standard)-1 {
+		Fail(t, "expected exactly one precompile to be removed", len(custom), len(standard))
+	}
+}
+
+func TestDescribePrecompiles(t *testing.T) {
+	descriptions, err := DescribePrecompiles()
+	Require(t, err)
+	precompiles, err := Precompiles()
+	Require(t, err)
+	if len(descriptions) != len(precompiles) {
+		Fail(t, "described", len(descriptions), "of", len(precompiles), "precompiles")
+	}
+
+	expected := precompiles[types.ArbSysAddress].Precompile().GetMethodID("ArbBlockNumber")
+	found := false
+	for _, description := range descriptions {
+		if description.Address != types.ArbSysAddress {
+			continue
+		}
+		if description.Name != "ArbSys" {
+			Fail(t, "ArbSys is described as", description.Name)
+		}
+		for _, method := range description.Methods {
+			if method.Signature != "arbBlockNumber()" {
+				continue
+			}
+			found = true
+			if method.Selector != expected || method.Mutability.String() != "view" {
+				Fail(t, "arbBlockNumber is misdescribed", method)
+			}
+			if len(method.Inputs) != 0 || len(method.Outputs) != 1 {
+				Fail(t, "arbBlockNumber has the wrong arguments", method.Inputs, method.Outputs)
+			}
+		}
+	}
+	if !found {
+		Fail(t, "arbBlockNumber wasn't described")
+	}
+}
+
+type FixedBytesTester struct {
+	Address addr
+}
+
+func (con FixedBytesTester) Digest(c ctx) (common.Hash, error) {
+	return common.HexToHash("0x0102"), nil
+}
+
+func (con FixedBytesTester) Selector(c ctx) (bytes4, error) {
+	return bytes4{0xde, 0xad, 0xbe, 0xef}, nil
+}
+
+type SliceForFixedBytesTester struct {
+	Address addr
+}
+
+func (con SliceForFixedBytesTester) Digest(c ctx) ([]byte, error) {
+	return []byte{0x01}, nil
+}
+
+type EnvironmentTester struct {
+	Address addr
+}
+
+func (con EnvironmentTester) Describe(c ctx, e env) (huge, huge, error) {
+	return e.BlockNumber, e.ChainID, nil
+}
+
+func TestMethodArbosVersions(t *testing.T) {
+	evm := newMockEVMForTestingAtArbosVersion(t, arbostypes.ArbosVersion_PrecompileRevertReasons)
+	address := common.HexToAddress("0x011e")
+	precompile := makeTestPrecompile(t, `[
+		{"type":"function","name":"peek","stateMutability":"view","inputs":[],"outputs":[]},
+		{"type":"function","name":"poke","stateMutability":"nonpayable","inputs":[],"outputs":[]}
+	]`, &GuardTester{Address: address})
+	version := arbosState.ArbOSVersion(evm.StateDB)
+	input := packTestCall(t, precompile, "Peek")
+
+	if err := precompile.SetMethodArbosVersions(map[string]uint64{"Missing": 1}); err == nil {
+		Fail(t, "expected an error gating a method that doesn't exist")
+	}
+
+	Require(t, precompile.SetMethodArbosVersions(map[string]uint64{"Peek": version + 1}))
+	output, _, err := callTestPrecompile(precompile, input, evm)
+	if !errors.Is(err, vm.ErrExecutionReverted) {
+		Fail(t, "expected a method from a future ArbOS version to revert but got", err)
+	}
+	reason, err := abi.UnpackRevert(output)
+	Require(t, err)
+	if reason != "ArbOS: method not available at this ArbOS version" {
+		Fail(t, "unexpected revert reason", reason)
+	}
+
+	Require(t, precompile.SetMethodArbosVersions(map[string]uint64{"Peek": version}))
+	_, _, err = callTestPrecompile(precompile, input, evm)
+	Require(t, err)
+
+	// older versions revert without a reason
+	oldEVM := newMockEVMForTestingAtArbosVersion(t, arbostypes.ArbosVersion_PrecompileRevertReasons-1)
+	output, _, err = callTestPrecompile(precompile, input, oldEVM)
+	if !errors.Is(err, vm.ErrExecutionReverted) || len(output) != 0 {
+		Fail(t, "expected a revert without data", output, err)
+	}
+}
+
+type VoidTester struct {
+	Address addr
+}
+
+func (con VoidTester) Touch(c ctx, evm mech) error {
+	return nil
+}
+
+type VoidWithOutputTester struct {
+	Address addr
+}
+
+func (con VoidWithOutputTester) Touch(c ctx, evm mech) (bool, error) {
+	return true, nil
+}
+
+func TestVoidMethods(t *testing.T) {
+	evm := newMockEVMForTesting()
+	precompile := makeTestPrecompile(t, touchABI, &VoidTester{Address: common.HexToAddress("0x0120")})
+
+	input := packTestCall(t, precompile, "Touch")
+	output, _, err := callTestPrecompile(precompile, input, evm)
+	Require(t, err)
+	if output == nil || len(output) != 0 {
+		Fail(t, "expected empty, non-nil output but got", output)
+	}
+}
+
+type ClearingTester struct {
+	Address addr
+}
+
+func (con ClearingTester) Clear(c ctx, evm mech) error {
+	c.Refund(params.SstoreClearsScheduleRefundEIP3529)
+	return nil
+}
+
+func TestGasRefunds(t *testing.T) {
+	evm := newMockEVMForTesting()
+	address := common.HexToAddress("0x0121")
+	precompile := makeTestPrecompile(t, `[
+		{"type":"function","name":"clear","stateMutability":"nonpayable","inputs":[],"outputs":[]}
+	]`, &ClearingTester{Address: address})
+
+	before := evm.StateDB.GetRefund()
+	input := packTestCall(t, precompile, "Clear")
+	_, _, err := callTestPrecompile(precompile, input, evm)
+	Require(t, err)
+	if refund := evm.StateDB.GetRefund() - before; refund != params.SstoreClearsScheduleRefundEIP3529 {
+		Fail(t, "unexpected refund", refund)
+	}
+}
+
+type OverflowTester struct {
+	Address addr
+}
+
+func (con OverflowTester) Narrow(c ctx, evm mech, value huge) (huge, error) {
+	return value, nil
+}
+
+func (con OverflowTester) Signed(c ctx, evm mech, value huge) (huge, error) {
+	return new(big.Int).Neg(value), nil
+}
+
+func TestMutability(t *testing.T) {
+	for _, mutability := range []Mutability{MutabilityPure, MutabilityView, MutabilityNonPayable, MutabilityPayable} {
+		parsed, ok := ParseMutability(mutability.String())
+		if !ok || parsed != mutability {
+			Fail(t, "mutability", mutability, "didn't round trip")
+		}
+	}
+	if _, ok := ParseMutability("constant"); ok {
+		Fail(t, "parsed an unknown mutability")
+	}
+
+	precompiles, err := Precompiles()
+	Require(t, err)
+	arbSys := precompiles[types.ArbSysAddress].Precompile()
+	for name, expected := range map[string]Mutability{
+		"ArbBlockNumber": MutabilityView,
+		"WithdrawEth":    MutabilityPayable,
+		"SendTxToL1":     MutabilityPayable,
+	} {
+		id := arbSys.GetMethodID(name)
+		mutability, ok := arbSys.Mutability(id)
+		if !ok || mutability != expected {
+			Fail(t, name, "has mutability", mutability, "instead of", expected)
+		}
+	}
+	if _, ok := arbSys.Mutability([4]byte{}); ok {
+		Fail(t, "found the mutability of a nonexistent method")
+	}
+}
+
+func TestWithSnapshot(t *testing.T) {
+	evm := newMockEVMForTesting()
+	table := testContext(common.Address{}, evm).State.AddressTable()
+	first := common.HexToAddress("0x01")
+	second := common.HexToAddress("0x02")
+
+	failure := errors.New("the second step failed")
+	err := WithSnapshot(evm, func() error {
+		if _, err := table.Register(first); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		Fail(t, "expected the update's error but got", err)
+	}
+	size, err := table.Size()
+	Require(t, err)
+	if size != 0 {
+		Fail(t, "the partial write wasn't rolled back")
+	}
+
+	Require(t, WithSnapshot(evm, func() error {
+		if _, err := table.Register(first); err != nil {
+			return err
+		}
+		_, err := table.Register(second)
+		return err
+	}))
+	size, err = table.Size()
+	Require(t, err)
+	if size != 2 {
+		Fail(t, "a successful update wasn't kept", size)
+	}
+}
+
+type WidthTester struct {
+	Address addr
+}
+
+func (con WidthTester) Echo(c ctx, evm mech, small uint8, word uint64, wide huge) (uint8, uint64, huge, error) {
+	return small, word, wide, nil
+}
+
+type NarrowingTester struct {
+	Address addr
+}
+
+func (con NarrowingTester) Echo(c ctx, evm mech, small uint8, word uint8, wide huge) (uint8, uint64, huge, error) {
+	return small, uint64(word), wide, nil
+}
+
+type NilValueTester struct {
+	Address addr
+}
+
+func (con NilValueTester) Deposit(c ctx, evm mech, value huge) (huge, error) {
+	return new(big.Int).Add(value, common.Big1), nil
+}
+
+func (con NilValueTester) Check(c ctx, evm mech) error {
+	return nil
+}
+
+func TestNilCallValue(t *testing.T) {
+	evm := newMockEVMForTesting()
+	address := common.HexToAddress("0x012b")
+	precompile := makeTestPrecompile(t, `[
+		{"type":"function","name":"deposit","stateMutability":"payable","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+		{"type":"function","name":"check","stateMutability":"nonpayable","inputs":[],"outputs":[]}
+	]`, &NilValueTester{Address: address})
+
+	input := packTestCall(t, precompile, "Deposit")
+	output, _, err := precompile.Call(input, address, address, common.Address{}, nil, false, 1000000, evm)
+	Require(t, err)
+	if new(big.Int).SetBytes(output).Int64() != 1 {
+		Fail(t, "a nil value wasn't treated as zero", output)
+	}
+
+	// the non-payable guard must also accept a nil value
+	input = packTestCall(t, precompile, "Check")
+	_, _, err = precompile.Call(input, address, address, common.Address{}, nil, false, 1000000, evm)
+	Require(t, err)
+}
+
+type ChainContextTester struct {
+	Address addr
+}
+
+func (con ChainContextTester) ChainId(c ctx, chain *ChainContext) (huge, error) {
+	return chain.ChainID, nil
+}
+
+func TestPureChainContext(t *testing.T) {
+	evm := newMockEVMForTesting()
+	address := common.HexToAddress("0x012d")
+	precompile := makeTestPrecompile(t, `[
+		{"type":"function","name":"chainId","stateMutability":"pure","inputs":[],"outputs":[{"name":"","type":"uint256"}]}
+	]`, &ChainContextTester{Address: address})
+	precompile.SetAllowDelegatecall(true)
+
+	// as a pure method, it may run even when another contract delegatecalls it
+	input := packTestCall(t, precompile, "ChainId")
+	delegator := common.HexToAddress("0x0d")
+	output, _, err := precompile.Call(input, address, delegator, common.Address{}, common.Big0, true, 1000000, evm)
+	Require(t, err)
+	if chainID := new(big.Int).SetBytes(output); chainID.Cmp(evm.ChainConfig().ChainID) != 0 {
+		Fail(t, "read chain id", chainID, "instead of", evm.ChainConfig().ChainID)
+	}
+}
+
+type StorageReaderTester struct {
+	Address addr
+}
+
+func (con StorageReaderTester) ReadSlot(c ctx, evm mech, slot bytes32) (bytes32, error) {
+	return c.ReadStorage(slot)
+}
+
+func (con StorageReaderTester) PeekSlot(c ctx, slot bytes32) (bytes32, error) {
+	return c.ReadStorage(slot)
+}
+
+func TestReadStorage(t *testing.T) {
+	evm := newMockEVMForTesting()
+	address := common.HexToAddress("0x0130")
+	precompile := makeTestPrecompile(t, `[
+		{"type":"function","name":"readSlot","stateMutability":"view","inputs":[{"name":"slot","type":"bytes32"}],"outputs":[{"name":"","type":"bytes32"}]},
+		{"type":"function","name":"peekSlot","stateMutability":"pure","inputs":[{"name":"slot","type":"bytes32"}],"outputs":[{"name":"","type":"bytes32"}]}
+	]`, &StorageReaderTester{Address: address})
+
+	slot := common.HexToHash("0x01")
+	value := common.HexToHash("0xabcd")
+	evm.StateDB.SetState(address, slot, value)
+	input := packTestCall(t, precompile, "ReadSlot", slot)
+	output, _, err := callTestPrecompile(precompile, input, evm)
+	Require(t, err)
+	if common.BytesToHash(output) != value {
+		Fail(t, "read", common.BytesToHash(output), "instead of", value)
+	}
+
+	// pure methods may not read state
+	input = packTestCall(t, precompile, "PeekSlot", slot)
+	if _, _, err := callTestPrecompile(precompile, input, evm); err == nil {
+		Fail(t, "a pure method read storage")
+	}
+}
+
+type ActingAsStorageTester struct {
+	Address addr
+}
+
+func (con ActingAsStorageTester) ReadSlot(c ctx, evm mech, slot bytes32) (bytes32, error) {
+	return c.ReadStorage(slot)
+}
+
+func (con ActingAsStorageTester) WriteSlot(c ctx, evm mech, slot bytes32, value bytes32) error {
+	return c.WriteStorage(slot, value)
+}
+
+func TestActingAsStorage(t *testing.T) {
+	evm := newMockEVMForTestingAtArbosVersion(t, arbostypes.ArbosVersion_PrecompileDelegatecallGuard)
+	address := common.HexToAddress("0x013d")
+	proxy := common.HexToAddress("0x013e")
+	precompile := makeTestPrecompile(t, `[
+		{"type":"function","name":"readSlot","stateMutability":"view","inputs":[{"name":"slot","type":"bytes32"}],"outputs":[{"name":"","type":"bytes32"}]},
+		{"type":"function","name":"writeSlot","stateMutability":"nonpayable","inputs":[{"name":"slot","type":"bytes32"},{"name":"value","type":"bytes32"}],"outputs":[]}
+	]`, &ActingAsStorageTester{Address: address})
+	precompile.SetAllowDelegatecall(true)
+
+	slot := common.HexToHash("0x01")
+	value := common.HexToHash("0xabcd")
+	delegatecall := func(readOnly bool, name string, args ...interface{}) ([]byte, error) {
+		input := packTestCall(t, precompile, name, args...)
+		output, _, err := precompile.Call(input, address, proxy, proxy, common.Big0, readOnly, 1000000, evm)
+		return output, err
+	}
+
+	// without opting in, impure methods can't be delegatecalled
+	if _, err := delegatecall(false, "WriteSlot", slot, value); !errors.Is(err, ErrNotActingAsSelf) {
+		Fail(t, "wrote storage without opting in", err)
+	}
+
+	precompile.SetAllowActingAsStorage(true)
+	_, err := delegatecall(false, "WriteSlot", slot, value)
+	Require(t, err)
+	if written := evm.StateDB.GetState(proxy, slot); written != value {
+		Fail(t, "wrote", written, "to the proxy instead of", value)
+	}
+	if written := evm.StateDB.GetState(address, slot); written != (common.Hash{}) {
+		Fail(t, "wrote", written, "to the precompile rather than the proxy")
+	}
+	output, err := delegatecall(false, "ReadSlot", slot)
+	Require(t, err)
+	if common.BytesToHash(output) != value {
+		Fail(t, "read", common.BytesToHash(output), "instead of", value)
+	}
+
+	// static calls still can't write
+	if _, err := delegatecall(true, "WriteSlot", slot, common.Hash{}); !errors.Is(err, ErrReadOnlyCall) {
+		Fail(t, "wrote storage in a static call", err)
+	}
+
+	// the opt-in depends on delegatecalls being allowed
+	precompile.SetAllowDelegatecall(false)
+	if _, err := delegatecall(false, "ReadSlot", slot); !errors.Is(err, ErrDelegatecall) {
+		Fail(t, "read storage without allowing delegatecalls", err)
+	}
+}
+
+type FallbackEchoTester struct {
+	Address addr
+}
+
+func (con FallbackEchoTester) Fallback(c ctx, evm mech, input []byte) ([]byte, error) {
+	return input, nil
+}
+
+func (con FallbackEchoTester) Echo(c ctx, evm mech, data []byte) ([]byte, error) {
+	return data, nil
+}
+
+func TestOutputsDontAliasInput(t *testing.T) {
+	evm := newMockEVMForTesting()
+	address := common.HexToAddress("0x0132")
+	precompile := makeTestPrecompile(t, `[
+		{"type":"function","name":"echo","stateMutability":"view","inputs":[{"name":"data","type":"bytes"}],"outputs":[{"name":"","type":"bytes"}]}
+	]`, &FallbackEchoTester{Address: address})
+
+	for _, input := range [][]byte{
+		packTestCall(t, precompile, "Echo", []byte{0x01, 0x02, 0x03}),
+		{0xaa, 0xbb, 0xcc, 0xdd, 0xee},
+	} {
+		output, _, err := callTestPrecompile(precompile, input, evm)
+		Require(t, err)
+		expected := common.CopyBytes(output)
+
+		// the evm may reuse the memory backing the calldata
+		for i := range input {
+			input[i] ^= 0xff
+		}
+		if !bytes.Equal(output, expected) {
+			Fail(t, "the output changed along with the input", output, expected)
+		}
+	}
+}
+
+func TestGasSumSaturates(t *testing.T) {
+	if sum := gasSum(1, 2, 3); sum != 6 {
+		Fail(t, "unexpected sum", sum)
+	}
+	if sum := gasSum(math.MaxUint64-1, 2); sum != math.MaxUint64 {
+		Fail(t, "sum wrapped to", sum)
+	}
+	if sum := gasSum(math.MaxUint64, math.MaxUint64, 1); sum != math.MaxUint64 {
+		Fail(t, "sum wrapped to", sum)
+	}
+}
+
+type ReentrancyTester struct {
+	Address    addr
+	precompile *Precompile
+	nestedErr  *error
+	calls      *int
+}
+
+func (con ReentrancyTester) Nest(c ctx, evm mech) error {
+	*con.calls++
+	if *con.calls > 1 {
+		return nil
+	}
+	// call back into the same precompile, as a contract executed by a handler might
+	input := con.precompile.methodsByName["Nest"].template.ID
+	_, _, err := con.precompile.Call(input, con.Address, con.Address, con.Address, big.NewInt(0), false, 100000, evm)
+	if err == nil {
+		err = errNestedSucceeded
+	}
+	*con.nestedErr = err
+	return nil
+}
+
+var errNestedSucceeded = errors.New("the nested call succeeded")
+
+func TestReentrancyGuard(t *testing.T) {
+	evm := newMockEVMForTesting()
+	address := common.HexToAddress("0x0136")
+	var nestedErr error
+	calls := 0
+	impl := &ReentrancyTester{Address: address, nestedErr: &nestedErr, calls: &calls}
+	precompile := makeTestPrecompile(t, `[
+		{"type":"function","name":"nest","stateMutability":"nonpayable","inputs":[],"outputs":[]}
+	]`, impl)
+	impl.precompile = precompile
+	input := packTestCall(t, precompile, "Nest")
+
+	// without the guard, the precompile may be reentered
+	_, _, err := callTestPrecompile(precompile, input, evm)
+	Require(t, err)
+	if !errors.Is(nestedErr, errNestedSucceeded) {
+		Fail(t, "unguarded reentrant call failed", nestedErr)
+	}
+
+	precompile.SetReentrancyGuard(true)
+	for i := 0; i < 2; i++ {
+		nestedErr = nil
+		calls = 0
+		_, _, err = callTestPrecompile(precompile, input, evm)
+		Require(t, err, "the outer call should succeed every time")
+		if !errors.Is(nestedErr, vm.ErrExecutionReverted) {
+			Fail(t, "reentrant call should revert but got", nestedErr)
+		}
+	}
+}
+
+type AddressMethodTester struct{}
+
+func (con AddressMethodTester) PrecompileAddress() addr {
+	return common.HexToAddress("0x0137")
+}
+
+func (con AddressMethodTester) Answer(c ctx, evm mech) (huge, error) {
+	return big.NewInt(42), nil
+}
+
+type OverriddenAddressTester struct {
+	AddressMethodTester
+	Address addr
+}
+
+func TestPrecompileAddresser(t *testing.T) {
+	evm := newMockEVMForTesting()
+	abiJSON := `[
+		{"type":"function","name":"answer","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]}
+	]`
+	address, precompile, err := MakePrecompile(&bind.MetaData{ABI: abiJSON}, &AddressMethodTester{})
+	Require(t, err)
+	if address != common.HexToAddress("0x0137") || precompile.address != address {
+		Fail(t, "precompile registered at", address, "instead of its method's address")
+	}
+
+	input := packTestCall(t, precompile, "Answer")
+	output, _, err := callTestPrecompile(precompile, input, evm)
+	Require(t, err)
+	if new(big.Int).SetBytes(output).Int64() != 42 {
+		Fail(t, "unexpected output", output)
+	}
+
+	// the method takes precedence over the field
+	address, _, err = MakePrecompile(&bind.MetaData{ABI: abiJSON}, &OverriddenAddressTester{Address: common.HexToAddress("0x0138")})
+	Require(t, err)
+	if address != common.HexToAddress("0x0137") {
+		Fail(t, "the Address field took precedence over the method", address)
+	}
+}
+
+type FixedArrayTester struct {
+	Address addr
+}
+
+func (con FixedArrayTester) Scale(c ctx, evm mech, key [4]huge) ([4]huge, error) {
+	var scaled [4]huge
+	for i, coordinate := range key {
+		scaled[i] = new(big.Int).Lsh(coordinate, 200)
+	}
+	return scaled, nil
+}
+
+type SliceForArrayTester struct {
+	Address addr
+}
+
+func (con SliceForArrayTester) Scale(c ctx, evm mech, key []huge) ([]huge, error) {
+	return key, nil
+}
+
+type BoolOutputTester struct {
+	Address addr
+}
+
+func (con BoolOutputTester) No(c ctx, evm mech) (bool, error) {
+	return false, nil
+}
+
+func (con BoolOutputTester) Yes(c ctx, evm mech) (bool, error) {
+	return true, nil
+}
+
+type FreeMethodTester struct {
+	Address addr
+}
+
+func (con FreeMethodTester) Free(c ctx, evm mech, x uint64) (uint64, error) {
+	return x, nil
+}
+
+func (con FreeMethodTester) FreeGasCost(x uint64) (uint64, error) {
+	return 0, nil
+}
+
+func TestGasFloor(t *testing.T) {
+	evm := newMockEVMForTesting()
+	address := common.HexToAddress("0x013c")
+	precompile := makeTestPrecompile(t, `[
+		{"type":"function","name":"free","stateMutability":"pure","inputs":[{"name":"x","type":"uint64"}],"outputs":[{"name":"","type":"uint64"}]}
+	]`, &FreeMethodTester{Address: address})
+	input := packTestCall(t, precompile, "Free", uint64(7))
+
+	call := func() uint64 {
+		t.Helper()
+		_, gasLeft, err := callTestPrecompile(precompile, input, evm)
+		Require(t, err)
+		return 1000000 - gasLeft
+	}
+	unfloored := call()
+	precompile.SetGasFloor(100)
+	if floored := call(); floored != unfloored+100 {
+		Fail(t, "the floor added", floored-unfloored, "gas instead of 100")
+	}
+
+	precompiles, err := Precompiles(WithGasFloor(100))
+	Require(t, err)
+	for address, contract := range precompiles {
+		if contract.Precompile().gasFloor != 100 {
+			Fail(t, "the precompile at", address, "has a floor of", contract.Precompile().gasFloor)
+		}
+	}
+}
+
+type FunctionArgTester struct {
+	Address addr
+}
+
+func (con FunctionArgTester) Forward(c ctx, evm mech, callback [24]byte) ([24]byte, error) {
+	return callback, nil
+}
+
+type FunctionSliceTester struct {
+	Address addr
+}
+
+func (con FunctionSliceTester) Forward(c ctx, evm mech, callback [24]byte) ([]byte, error) {
+	return callback[:4], nil
+}
+
+func TestPrecompileNames(t *testing.T) {
+	names, err := PrecompileNames()
+	Require(t, err)
+	if names[types.ArbSysAddress] != "ArbSys" || names[common.HexToAddress("70")] != "ArbOwner" {
+		Fail(t, "unexpected precompile names", names)
+	}
+
+	// introspection doesn't reinstall the hooks ArbOS uses, which block processing reads concurrently
+	original := arbos.InternalTxStartBlockMethodID
+	defer func() { arbos.InternalTxStartBlockMethodID = original }()
+	arbos.InternalTxStartBlockMethodID = bytes4{0xde, 0xad, 0xbe, 0xef}
+	_, err = PrecompileNames()
+	Require(t, err)
+	_, err = ExportSelectorsJSON()
+	Require(t, err)
+	if arbos.InternalTxStartBlockMethodID != (bytes4{0xde, 0xad, 0xbe, 0xef}) {
+		Fail(t, "introspection reinstalled ArbOS's hooks")
+	}
+}
+
+type RefundTester struct {
+	Address addr
+}
+
+func (con RefundTester) Deposit(c ctx, evm mech, value huge, refund huge) error {
+	return c.RefundValue(refund)
+}
+
+func TestValueRefund(t *testing.T) {
+	evm := newMockEVMForTesting()
+	address := common.HexToAddress("0x0108")
+	caller := common.HexToAddress("0x0109")
+	precompile := makeTestPrecompile(t, `[
+		{"type":"function","name":"deposit","stateMutability":"payable",
+		 "inputs":[{"name":"refund","type":"uint256"}],"outputs":[]}
+	]`, &RefundTester{Address: address})
+
+	// the EVM deposits the callvalue into the precompile before calling it
+	evm.StateDB.AddBalance(address, big.NewInt(10))
+	input := packTestCall(t, precompile, "Deposit", big.NewInt(7))
+	_, _, err := precompile.Call(input, address, address, caller, big.NewInt(10), false, 1000000, evm)
+	Require(t, err)
+
+	if balance := evm.StateDB.GetBalance(address); balance.Cmp(big.NewInt(3)) != 0 {
+		Fail(t, "precompile kept", balance, "instead of", 3)
+	}
+	if balance := evm.StateDB.GetBalance(caller); balance.Cmp(big.NewInt(7)) != 0 {
+		Fail(t, "caller was refunded", balance, "instead of", 7)
+	}
+
+	// refunding more than was paid reverts
+	input = packTestCall(t, precompile, "Deposit", big.NewInt(2))
+	_, _, err = precompile.Call(input, address, address, caller, big.NewInt(1), false, 1000000, evm)
+	if err == nil {
+		Fail(t, "over-refunding should revert")
+	}
+}
+
+type OwnerOnlyTester struct {
+	Address addr
+}
+
+func (con OwnerOnlyTester) Open(c ctx, evm mech) error {
+	return nil
+}
+
+func (con OwnerOnlyTester) Restricted(c ctx, evm mech) error {
+	return nil
+}
+
+func TestOwnerOnlyMethods(t *testing.T) {
+	evm := newMockEVMForTesting()
+	address := common.HexToAddress("0x010a")
+	caller := common.HexToAddress("0x010b")
+	precompile := makeTestPrecompile(t, `[
+		{"type":"function","name":"open","stateMutability":"nonpayable","inputs":[],"outputs":[]},
+		{"type":"function","name":"restricted","stateMutability":"nonpayable","inputs":[],"outputs":[]}
+	]`, &OwnerOnlyTester{Address: address})
+	Require(t, precompile.SetOwnerOnly(IsChainOwner, "Restricted"))
+
+	call := func(name string) ([]byte, error) {
+		output, _, err := precompile.Call(
+			packTestCall(t, precompile, name), address, address, caller, common.Big0, false, 1000000, evm,
+		)
+		return output, err
+	}
+
+	_, err := call("Open")
+	Require(t, err)
+
+	output, err := call("Restricted")
+	if !errors.Is(err, vm.ErrExecutionReverted) {
+		Fail(t, "expected non-owner to be rejected but got", err)
+	}
+	reason, err := abi.UnpackRevert(output)
+	Require(t, err)
+	if reason != "ArbOS: caller is not authorized to call restricted" {
+		Fail(t, "unexpected revert reason", reason)
+	}
+
+	Require(t, testContext(common.Address{}, evm).State.ChainOwners().Add(caller))
+	_, err = call("Restricted")
+	Require(t, err)
+}
+
+func TestZeroArgumentTrailingCalldata(t *testing.T) {
+	evm := newMockEVMForTesting()
+	precompiles, err := Precompiles()
+	Require(t, err)
+	arbSys := precompiles[types.ArbSysAddress]
+	id := arbSys.Precompile().GetMethodID("ArbBlockNumber")
+
+	call := func(input []byte) []byte {
+		t.Helper()
+		output, _, err := arbSys.Call(input, types.ArbSysAddress, types.ArbSysAddress, common.Address{}, common.Big0, false, 1000000, evm)
+		Require(t, err)
+		return output
+	}
+
+	// skipping the decoder mustn't change how extra calldata is treated
+	expected := call(id[:])
+	if output := call(append(id[:], 0xde, 0xad)); !bytes.Equal(output, expected) {
+		Fail(t, "trailing calldata changed the result", output, expected)
+	}
+}
+
+func BenchmarkArbBlockNumber(b *testing.B) {
+	evm := newMockEVMForTesting()
+	precompiles, err := Precompiles()
+	if err != nil {
+		b.Fatal(err)
+	}
+	arbSys := precompiles[types.ArbSysAddress]
+	id := arbSys.Precompile().GetMethodID("ArbBlockNumber")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, err := arbSys.Call(
+			id[:], types.ArbSysAddress, types.ArbSysAddress, common.Address{}, common.Big0, false, 1000000, evm,
+		)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+type ManyArgsTester struct {
+	Address addr
+}
+
+func (con ManyArgsTester) Eight(c ctx, evm mech, a, b, c2, d, e, f, g, h uint64) (uint64, error) {
+	return a + b + c2 + d + e + f + g + h, nil
+}
+
+// legacyHandlerArgs lays out a handler's args as dispatch did before the layout was precomputed, branching on
+// purity and looking up each input's type from the handler on every call
+func legacyHandlerArgs(method *PrecompileMethod, implementer reflect.Value, callerCtx *Context, evm *vm.EVM, value *big.Int, args []interface{}) []reflect.Value {
+	reflectArgs := []reflect.Value{implementer, reflect.ValueOf(callerCtx)}
+	switch method.purity {
+	case pure:
+	case view, write:
+		reflectArgs = append(reflectArgs, reflect.ValueOf(evm))
+	case payable:
+		reflectArgs = append(reflectArgs, reflect.ValueOf(evm))
+		reflectArgs = append(reflectArgs, reflect.ValueOf(value))
+	}
+	for _, arg := range args {
+		converted := reflect.ValueOf(arg).Convert(method.handler.Type.In(len(reflectArgs)))
+		reflectArgs = append(reflectArgs, converted)
+	}
+	return reflectArgs
+}
+
+// BenchmarkDispatchPaths compares laying out and calling a handler the legacy way against the precomputed layout,
+// alongside a full call of the precompile for context. Run it with -benchtime=100000x to compare tight loops of 100k calls.
+func BenchmarkDispatchPaths(b *testing.B) {
+	evm := newMockEVMForTesting()
+	address := common.HexToAddress("0x012c")
+	_, precompile, err := MakePrecompile(&bind.MetaData{ABI: `[
+		{"type":"function","name":"eight","stateMutability":"view","inputs":[` + strings.Repeat(`{"name":"","type":"uint64"},`, 7) + `{"name":"","type":"uint64"}],"outputs":[{"name":"","type":"uint64"}]}
+	]`}, &ManyArgsTester{Address: address})
+	if err != nil {
+		b.Fatal(err)
+	}
+	method := precompile.methodsByName["Eight"]
+	args := []interface{}{}
+	for i := uint64(0); i < 8; i++ {
+		args = append(args, i)
+	}
+	callerCtx := testContext(common.Address{}, evm)
+	id := precompile.GetMethodID("Eight")
+	input := append([]byte{}, id[:]...)
+	for i := 0; i < 8; i++ {
+		input = append(input, common.BigToHash(big.NewInt(int64(i))).Bytes()...)
+	}
+
+	b.Run("call", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, _, err := callTestPrecompile(precompile, input, evm); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("legacy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			reflectArgs := legacyHandlerArgs(method, precompile.implementer, callerCtx, evm, common.Big0, args)
+			method.handler.Func.Call(reflectArgs)
+		}
+	})
+	b.Run("precomputed", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			reflectArgs := method.handlerArgs(precompile.implementer, callerCtx, evm, common.Big0)
+			for j, arg := range args {
+				reflectArgs = append(reflectArgs, reflect.ValueOf(arg).Convert(method.inputTypes[j]))
+			}
+			method.handler.Func.Call(reflectArgs)
+		}
+	})
+}
+
+type ConstantCostTester struct {
+	Address addr
+	priced  *int
+}
+
+func (con ConstantCostTester) Double(c ctx, evm mech, x uint64) (uint64, error) {
+	return 2 * x, nil
+}
+
+func (con ConstantCostTester) DoubleGasCost() (uint64, error) {
+	*con.priced++
+	return 5000, nil
+}
+
+type InputCostTester struct {
+	Address addr
+}
+
+func (con InputCostTester) Double(c ctx, evm mech, x uint64) (uint64, error) {
+	return 2 * x, nil
+}
+
+func (con InputCostTester) DoubleGasCost(x uint64) (uint64, error) {
+	return 5000, nil
+}
+
+const doubleABI = `[
+	{"type":"function","name":"double","stateMutability":"view","inputs":[{"name":"x","type":"uint64"}],"outputs":[{"name":"","type":"uint64"}]}
+]`
+
+func TestConstantGasCost(t *testing.T) {
+	evm := newMockEVMForTesting()
+	address := common.HexToAddress("0x0139")
+	priced := 0
+	constant := makeTestPrecompile(t, doubleABI, &ConstantCostTester{Address: address, priced: &priced})
+	reflected := makeTestPrecompile(t, doubleABI, &InputCostTester{Address: address})
+	input := packTestCall(t, constant, "Double", uint64(21))
+
+	var gasLeft [2]uint64
+	for i, precompile := range []*Precompile{constant, reflected} {
+		output, left, err := callTestPrecompile(precompile, input, evm)
+		Require(t, err)
+		if new(big.Int).SetBytes(output).Uint64() != 42 {
+			Fail(t, "unexpected output", output)
+		}
+		gasLeft[i] = left
+	}
+	if gasLeft[0] != gasLeft[1] || gasLeft[0] > 1000000-5000 {
+		Fail(t, "the constant cost was charged differently", gasLeft)
+	}
+	if priced != 1 {
+		Fail(t, "the constant cost was priced", priced, "times instead of once")
+	}
+
+	_, _, err := constant.Call(input, address, address, common.Address{}, big.NewInt(0), true, 4000, evm)
+	if !errors.Is(err, vm.ErrExecutionReverted) {
+		Fail(t, "a call that can't afford the constant cost should revert but got", err)
+	}
+
+	// validation doesn't price the zero-valued instance it makes, whose nil counter would panic
+	Require(t, ValidateImplementer(&bind.MetaData{ABI: doubleABI}, &ConstantCostTester{}))
+	if _, _, err := MakePrecompile(&bind.MetaData{ABI: doubleABI}, &ConstantCostTester{Address: address}); err == nil {
+		Fail(t, "expected a panicking constant cost to be reported")
+	}
+}
+
+// BenchmarkConstantGasCost compares a constant cost to one priced by reflection on every call
+func BenchmarkConstantGasCost(b *testing.B) {
+	evm := newMockEVMForTesting()
+	address := common.HexToAddress("0x0139")
+	priced := 0
+	implementers := map[string]interface{}{
+		"constant":  &ConstantCostTester{Address: address, priced: &priced},
+		"reflected": &InputCostTester{Address: address},
+	}
+	for name, implementer := range implementers {
+		_, precompile, err := MakePrecompile(&bind.MetaData{ABI: doubleABI}, implementer)
+		if err != nil {
+			b.Fatal(err)
+		}
+		id := precompile.GetMethodID("Double")
+		input := append(append([]byte{}, id[:]...), common.BigToHash(big.NewInt(21)).Bytes()...)
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, _, err := callTestPrecompile(precompile, input, evm)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkPrecompilesForManyChains constructs the precompiles as a process hosting 50 chains would
+func BenchmarkPrecompilesForManyChains(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for chain := 0; chain < 50; chain++ {
+			if _, err := Precompiles(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkEmit1000Events(b *testing.B) {
+	evm := newMockEVMForTesting()
+	impl := &IndexedStringTester{Address: common.HexToAddress("0x0102")}
+	_, _, err := MakePrecompile(&bind.MetaData{ABI: namedEventABI}, impl)
+	if err != nil {
+		b.Fatal(err)
+	}
+	context := testContext(common.Address{}, evm)
+	value := big.NewInt(1)
+
+	// emitting used to price the log through its GasCost before packing the data again
+	b.Run("packed twice", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < 1000; j++ {
+				if _, err := impl.NamedGasCost("entry", nil, value); err != nil {
+					b.Fatal(err)
+				}
+				if err := impl.Named(context, evm, "entry", nil, value); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("packed once", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < 1000; j++ {
+				if err := impl.Named(context, evm, "entry", nil, value); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
+
+// makeTestPrecompile builds a precompile from an inline solidity ABI
+func makeTestPrecompile(t *testing.T, abiJSON string, implementer interface{}) *Precompile {
+	t.Helper()
+	_, precompile, err := MakePrecompile(&bind.MetaData{ABI: abiJSON}, implementer)
+	Require(t, err)
+	return precompile
+}
+
+// packTestCall encodes calldata for the precompile method with the given go name
+func packTestCall(t *testing.T, precompile *Precompile, name string, args ...interface{}) []byte {
+	t.Helper()
+	method, ok := precompile.methodsByName[name]
+	if !ok {
+		Fail(t, "precompile", precompile.name, "has no method", name)
+	}
+	packed, err := method.template.Inputs.Pack(args...)
+	Require(t, err)
+	return append(common.CopyBytes(method.template.ID), packed...)
+}
+
+// callTestPrecompile calls a test precompile at its own address, as an eoa would without value
+func callTestPrecompile(precompile *Precompile, input []byte, evm mech) ([]byte, uint64, error) {
+	return precompile.Call(input, precompile.address, precompile.address, common.Address{}, common.Big0, false, 1000000, evm)
+}
+
+// callTestMethod calls a method of a precompile as callTestPrecompile does, returning its unpacked outputs
+func callTestMethod(t *testing.T, precompile *Precompile, evm mech, name string, args ...interface{}) []interface{} {
+	t.Helper()
+	output, _, err := callTestPrecompile(precompile, packTestCall(t, precompile, name, args...), evm)
+	Require(t, err)
+	results, err := precompile.methodsByName[name].template.Outputs.Unpack(output)
+	Require(t, err)
+	return results
+}
+
 type FatalBurner struct {
 	t       *testing.T
 	count   uint64
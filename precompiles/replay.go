@@ -0,0 +1,110 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/OffchainLabs/nitro/blob/master/LICENSE
+
+package precompiles
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// StateAccess is a single storage read or write made during a replayed call
+type StateAccess struct {
+	Write   bool
+	Account common.Address
+	Key     common.Hash
+	Value   common.Hash
+}
+
+// recordingStateDB reports the storage accesses and, when onLog is set, the logs made through it
+type recordingStateDB struct {
+	vm.StateDB
+	onAccess func(StateAccess)
+	onLog    func(*types.Log)
+}
+
+func (db *recordingStateDB) GetState(account common.Address, key common.Hash) common.Hash {
+	value := db.StateDB.GetState(account, key)
+	db.onAccess(StateAccess{false, account, key, value})
+	return value
+}
+
+func (db *recordingStateDB) GetCommittedState(account common.Address, key common.Hash) common.Hash {
+	value := db.StateDB.GetCommittedState(account, key)
+	db.onAccess(StateAccess{false, account, key, value})
+	return value
+}
+
+func (db *recordingStateDB) SetState(account common.Address, key, value common.Hash) {
+	db.onAccess(StateAccess{true, account, key, value})
+	db.StateDB.SetState(account, key, value)
+}
+
+func (db *recordingStateDB) AddLog(log *types.Log) {
+	if db.onLog != nil {
+		db.onLog(log)
+	}
+	db.StateDB.AddLog(log)
+}
+
+// ReplayCall re-executes a precompile call against the evm's state, such as that of a historical block,
+// reporting each storage access in order to onAccess when it isn't nil. Like DryRunCall, it reverts the
+// state afterward, so replaying a write leaves no trace.
+func ReplayCall(
+	precompile ArbosPrecompile,
+	input []byte,
+	precompileAddress common.Address,
+	caller common.Address,
+	value *big.Int,
+	readOnly bool,
+	gasSupplied uint64,
+	evm *vm.EVM,
+	onAccess func(StateAccess),
+) ([]byte, uint64, error) {
+	original := evm.StateDB
+	snapshot := original.Snapshot()
+	defer original.RevertToSnapshot(snapshot)
+
+	if onAccess != nil {
+		evm.StateDB = &recordingStateDB{StateDB: original, onAccess: onAccess}
+		defer func() { evm.StateDB = original }()
+	}
+	return precompile.Call(input, precompileAddress, precompileAddress, caller, value, readOnly, gasSupplied, evm)
+}
+
+// DryRunCall executes a precompile call without persisting its effects, returning the storage writes it would
+// make in order, and the logs it would emit.
+func DryRunCall(
+	precompile ArbosPrecompile,
+	input []byte,
+	precompileAddress common.Address,
+	caller common.Address,
+	value *big.Int,
+	gasSupplied uint64,
+	evm *vm.EVM,
+) (output []byte, gasLeft uint64, writes []StateAccess, logs []*types.Log, err error) {
+	original := evm.StateDB
+	snapshot := original.Snapshot()
+	defer original.RevertToSnapshot(snapshot)
+
+	writes = []StateAccess{}
+	logs = []*types.Log{}
+	evm.StateDB = &recordingStateDB{
+		StateDB: original,
+		onAccess: func(access StateAccess) {
+			if access.Write {
+				writes = append(writes, access)
+			}
+		},
+		onLog: func(log *types.Log) {
+			logs = append(logs, log)
+		},
+	}
+	defer func() { evm.StateDB = original }()
+
+	output, gasLeft, err = precompile.Call(input, precompileAddress, precompileAddress, caller, value, false, gasSupplied, evm)
+	return output, gasLeft, writes, logs, err
+}
@@ -104,7 +104,8 @@ func (wrapper *OwnerPrecompile) Call(
 	version := arbosState.ArbOSVersion(evm.StateDB)
 	if !readOnly || version < 11 {
 		// log that the owner operation succeeded
-		if err := wrapper.emitSuccess(evm, *(*[4]byte)(input[:4]), caller, input); err != nil {
+		id, _ := selectorFromInput(input) // the call succeeded, so the input has a selector
+		if err := wrapper.emitSuccess(evm, id, caller, input); err != nil {
 			log.Error("failed to emit OwnerActs event", "err", err)
 		}
 	}
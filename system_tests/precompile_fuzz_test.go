@@ -25,6 +25,11 @@ const fuzzGas uint64 = 1200000
 func FuzzPrecompiles(f *testing.F) {
 	gethhook.RequireHookedGeth()
 
+	arbosPrecompiles, err := precompiles.Precompiles()
+	if err != nil {
+		panic(err)
+	}
+
 	f.Fuzz(func(t *testing.T, precompileSelector byte, methodSelector byte, input []byte) {
 		// Create a StateDB
 		sdb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
@@ -61,7 +66,7 @@ func FuzzPrecompiles(f *testing.F) {
 		addr[19] = precompileSelector
 
 		// Pick a precompile method based on the second byte of the input
-		if precompile := precompiles.Precompiles()[addr]; precompile != nil {
+		if precompile := arbosPrecompiles[addr]; precompile != nil {
 			sigs := precompile.Precompile().Get4ByteMethodSignatures()
 			if int(methodSelector) < len(sigs) {
 				newInput := make([]byte, 4)